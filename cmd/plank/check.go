@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/parser/types"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var checkCommand = &command{
+	name:    "check",
+	usage:   "check [-format human|json|sarif] file.plank|directory...",
+	summary: "check files without rendering them",
+}
+
+func init() {
+	checkCommand.run = runCheck
+}
+
+// diagnostic is a problem found in a file.
+type diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`   // starting at 1, 0 when unknown
+	Column   int    `json:"column"` // starting at 1, in characters, 0 when unknown
+	Severity string `json:"severity"`
+	Code     string `json:"code,omitempty"` // e.g. P0003, see parser.Code
+	Stage    string `json:"stage"`          // see stage, or lint
+	Message  string `json:"message"`
+
+	files *token.FileSet // the sources of the file and of the files it includes
+	err   error
+	warn  *parser.Warning
+}
+
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(checkCommand, stderr)
+	format := fs.String("format", "human", "output format: human, json or sarif")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(paths) == 0 || (*format != "human" && *format != "json" && *format != "sarif") {
+		fs.Usage()
+		return exitUsage
+	}
+
+	files, err := plankFiles(paths)
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	var diags []*diagnostic
+	errs, warns := 0, 0
+	for _, file := range files {
+		for _, d := range checkFile(file) {
+			if d.warn != nil {
+				warns++
+			} else {
+				errs++
+			}
+			diags = append(diags, d)
+		}
+	}
+
+	switch *format {
+	case "json":
+		err = writeJSON(stdout, diags)
+	case "sarif":
+		err = writeJSON(stdout, sarif(diags))
+	default:
+		for _, d := range diags {
+			if d.warn != nil {
+				printWarning(stdout, d.files, d.File, d.warn)
+			} else {
+				printError(stdout, d.files, d.File, d.err)
+			}
+		}
+		fmt.Fprintf(stdout, "%d files checked, %d with errors", len(files), errs)
+		if warns > 0 {
+			fmt.Fprintf(stdout, ", %d warnings", warns)
+		}
+		fmt.Fprintln(stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	if errs > 0 { // warnings alone do not fail the check
+		return exitError
+	}
+	return exitOK
+}
+
+// plankFiles returns the files of paths, replacing the directories by the .plank files they contain.
+func plankFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".plank" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// checkFile compiles the file at path and returns its error, or its warnings if it has no error.
+func checkFile(path string) []*diagnostic {
+	files := token.NewFileSet()
+	doc, err := compileFile(vfs.OS(), path, files, goplank.Limits{})
+	if err == nil {
+		var diags []*diagnostic
+		for _, w := range doc.Warnings() {
+			d := &diagnostic{File: path, Severity: "warning", Code: w.Code, Stage: "lint", Message: w.Message, files: files, warn: w}
+			if f := files.Lookup(w.Pos.File); f != nil {
+				d.Line, d.Column = position(f.Source(), w.Pos.Line, w.Pos.Column)
+			}
+			diags = append(diags, d)
+		}
+		return diags
+	}
+	d := &diagnostic{File: path, Severity: "error", Stage: stage(err), Code: parser.Code(err), files: files, err: err}
+	d.Message = strings.ReplaceAll(err.Error(), "\n", ": ")
+	var located *errorshelper.Error
+	if errors.As(err, &located) {
+		d.Message = strings.ReplaceAll(located.Err.Error(), "\n", ": ")
+		var src string
+		if f := sourceFile(files, err); f != nil {
+			d.File, src = filepath.FromSlash(f.Name()), f.Source()
+		}
+		d.Line, d.Column = position(src, located.Line, located.Column)
+	}
+	return []*diagnostic{d}
+}
+
+// stage returns the kind of err, from its type: io for the errors reading the files, syntax, type or semantic for the
+// errors of the sources, limit or internal.
+func stage(err error) string {
+	var (
+		limit   *errorshelper.LimitError
+		syntax  *lexer.SyntaxError
+		typ     *types.TypeError
+		located *errorshelper.Error
+	)
+	switch {
+	case errors.As(err, &limit):
+		return "limit"
+	case errors.Is(err, parser.ErrInternal), errors.Is(err, parser.ErrUnknownValue):
+		return "internal"
+	case errors.As(err, &syntax):
+		return "syntax"
+	case errors.As(err, &typ):
+		return "type"
+	case errors.As(err, &located):
+		return "semantic"
+	}
+	return "io"
+}
+
+// position returns the line and the column in characters, starting at 1, of the byte column col of the line ln of
+// src, starting at 0.
+func position(src string, ln, col int) (int, int) {
+	if lines := strings.Split(src, "\n"); ln < len(lines) && col <= len(lines[ln]) {
+		return ln + 1, utf8.RuneCountInString(lines[ln][:col]) + 1
+	}
+	return ln + 1, 1
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/planklang/goplank"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"ok.plank":        "plot [1 2 3]\n",
+		"sub/bad.plank":   "plot [1 2]\nplot [1 2] | colr red\n",
+		"sub/ignored.txt": "not plank",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"check", filepath.Join(dir, "ok.plank")}, &stdout, &stderr); code != exitOK {
+		t.Error("Expected", exitOK, "got", code, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"check", dir}, &stdout, &stderr); code != exitError {
+		t.Error("Expected", exitError, "got", code)
+	}
+	if !strings.Contains(stdout.String(), "bad.plank:2:14") || !strings.Contains(stdout.String(), "2 files checked, 1 with errors") {
+		t.Error("Expected a diagnostic for bad.plank, got", stdout.String())
+	}
+
+	stdout.Reset()
+	run([]string{"check", "-format", "json", dir}, &stdout, &stderr)
+	var diags []diagnostic
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 {
+		t.Fatal("Expected 1, got", len(diags))
+	}
+	if d := diags[0]; d.Line != 2 || d.Column != 14 || d.Stage != "semantic" || d.Code != "E0002" || !strings.HasPrefix(d.Message, "invalid modifier: ") {
+		t.Error("Expected a semantic error at 2:14, got", d)
+	}
+
+	stdout.Reset()
+	run([]string{"check", "-format", "sarif", dir}, &stdout, &stderr)
+	var log sarifLog
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatal("Expected 1 result, got", stdout.String())
+	}
+	if rule := log.Runs[0].Results[0].RuleID; rule != "E0002" {
+		t.Error("Expected the rule E0002, got", rule)
+	}
+	loc := log.Runs[0].Results[0].Locations[0].PhysicalLocation
+	if !strings.HasPrefix(loc.ArtifactLocation.URI, "file://") || !strings.HasSuffix(loc.ArtifactLocation.URI, "sub/bad.plank") {
+		t.Error("Expected the URI of bad.plank, got", loc.ArtifactLocation.URI)
+	}
+	if loc.Region == nil || loc.Region.StartLine != 2 {
+		t.Error("Expected line 2, got", loc.Region)
+	}
+
+	warn := writeFile(t, "warn.plank", "let unused [1 2]\nplot [1 2] | color red | color blue # plank:ignore W0004\n")
+	stdout.Reset()
+	if code := run([]string{"check", warn}, &stdout, &stderr); code != exitOK {
+		t.Error("Expected", exitOK, "with warnings only, got", code)
+	}
+	if out := stdout.String(); !strings.Contains(out, "warning[W0001]") || strings.Contains(out, "W0004") || !strings.Contains(out, "1 files checked, 0 with errors, 1 warnings") {
+		t.Error("Expected the unused variable warning, got", out)
+	}
+	stdout.Reset()
+	run([]string{"check", "-format", "json", warn}, &stdout, &stderr)
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Severity != "warning" || diags[0].Code != "W0001" || diags[0].Line != 1 || diags[0].Stage != "lint" {
+		t.Error("Expected a warning at line 1, got", diags)
+	}
+
+	inc := writeFile(t, "inc.plank", "include \"common.plank\"\nplot [1 2]\n")
+	common := filepath.Join(filepath.Dir(inc), "common.plank")
+	if err := os.WriteFile(common, []byte("let c red\ndefault plot | colr $c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := run([]string{"check", inc}, &stdout, &stderr); code != exitError || !strings.Contains(stdout.String(), "--> "+common+":2:16") {
+		t.Error("Expected the error in the included file, got", code, stdout.String())
+	}
+
+	if code := run([]string{"check", "-format", "xml", dir}, &stdout, &stderr); code != exitUsage {
+		t.Error("Expected", exitUsage, "got", code)
+	}
+}
+
+func TestStage(t *testing.T) {
+	for src, expected := range map[string]string{
+		"plot [1 2":                  "syntax",
+		"plot [1 \"s\" 3]":           "type",
+		"plot [1 2] | color 1 2.5 3": "type",
+		"plot [1 2] | colr red":      "semantic",
+		"plot $xs":                   "semantic",
+		"include \"missing.plank\"":  "semantic",
+		"plot [1 2 3 4]":             "limit",
+	} {
+		_, err := goplank.Compile(src, &goplank.Options{FS: fstest.MapFS{}, Limits: goplank.Limits{MaxPoints: 3}})
+		if s := stage(err); s != expected {
+			t.Error("Expected", expected, "for", src, "got", s, err)
+		}
+	}
+	if _, err := compileFile(vfs.OS(), "missing.plank", token.NewFileSet(), goplank.Limits{}); stage(err) != "io" {
+		t.Error("Expected io, got", stage(err), err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/token"
+	"golang.org/x/term"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// compileFile compiles the file at path in fsys, registering its source and the sources of the files it includes in
+// files. The files it includes are read from fsys, relative to the directory of path.
+func compileFile(fsys fs.FS, path string, files *token.FileSet, limits goplank.Limits) (*goplank.Document, error) {
+	return goplank.CompileFile(filepath.ToSlash(path), &goplank.Options{FS: fsys, FileSet: files, Limits: limits})
+}
+
+// printError writes the diagnostic of err, an error of a source of files: the file it is located in, or the source
+// registered without name, shown as unnamed. Errors without code, like the errors reading the compiled file, are
+// written on a line. It is colored when w is a terminal, unless the NO_COLOR environment variable is set.
+func printError(w io.Writer, files *token.FileSet, unnamed string, err error) {
+	if parser.Code(err) == "" {
+		fmt.Fprintf(w, "plank: %s\n", err)
+		return
+	}
+	d := parser.DiagnoseFiles(err, files)
+	d.Help = append(d.Help, "run \"plank explain "+d.Code+"\" for more information")
+	if l, ok := d.Primary(); ok && l.File != "" {
+		d.RenderFiles(w, files, colored(w))
+		return
+	}
+	var src string
+	if f := files.Lookup(""); f != nil {
+		src = f.Source()
+	}
+	d.Render(w, unnamed, src, colored(w))
+}
+
+// printWarning writes the diagnostic of w, a warning of a source of files, colored like printError.
+func printWarning(out io.Writer, files *token.FileSet, unnamed string, w *parser.Warning) {
+	path, src := unnamed, ""
+	if f := files.Lookup(w.Pos.File); f != nil {
+		src = f.Source()
+		if f.Name() != "" {
+			path = filepath.FromSlash(f.Name())
+		}
+	}
+	d := w.Diagnostic(src)
+	d.Help = append(d.Help, "run \"plank explain "+d.Code+"\" for more information")
+	d.Render(out, path, src, colored(out))
+}
+
+// sourceFile returns the file of files err is located in, or nil if it has none.
+func sourceFile(files *token.FileSet, err error) *token.File {
+	var located *errorshelper.Error
+	if !errors.As(err, &located) {
+		return nil
+	}
+	return files.Lookup(located.File)
+}
+
+// formatError returns the diagnostic of err like printError, without color.
+func formatError(files *token.FileSet, unnamed string, err error) string {
+	var b strings.Builder
+	printError(&b, files, unnamed, err)
+	return b.String()
+}
+
+// colored returns true if the diagnostics written to w may use colors.
+func colored(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(f.Fd()))
+}
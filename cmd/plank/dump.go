@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/planklang/goplank"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var tokensCommand = &command{
+	name:    "tokens",
+	usage:   "tokens file.plank",
+	summary: "print the tokens of a file with their positions, for debugging",
+}
+
+var astCommand = &command{
+	name:    "ast",
+	usage:   "ast [-eval] file.plank",
+	summary: "print the syntax tree of a file as JSON, for debugging",
+}
+
+func init() {
+	tokensCommand.run = runTokens
+	astCommand.run = runAst
+}
+
+func runTokens(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(tokensCommand, stderr)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	sources := token.NewFileSet()
+	lex, err := (&lexer.Config{File: filepath.ToSlash(files[0]), FileSet: sources}).Lex(context.Background(), string(b))
+	if err != nil {
+		printError(stderr, sources, files[0], err)
+		return exitError
+	}
+	writeTokens(stdout, lex)
+	return exitOK
+}
+
+func runAst(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(astCommand, stderr)
+	eval := fs.Bool("eval", false, "evaluate the tree, adding the axes and the plots of the figures after the defaults and the overwrites")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	sources := token.NewFileSet()
+	if *eval {
+		doc, err := compileFile(vfs.OS(), files[0], sources, goplank.Limits{})
+		if err != nil {
+			printError(stderr, sources, files[0], err)
+			return exitError
+		}
+		fmt.Fprintln(stdout, doc.Ast())
+		return exitOK
+	}
+
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	name := filepath.ToSlash(files[0])
+	lex, err := (&lexer.Config{File: name, FileSet: sources}).Lex(context.Background(), string(b))
+	if err == nil {
+		var tree *parser.Ast
+		if tree, err = (&parser.Config{FS: vfs.OS(), File: name}).Parse(context.Background(), lex); err == nil {
+			fmt.Fprintln(stdout, tree)
+			return exitOK
+		}
+	}
+	printError(stderr, sources, files[0], err)
+	return exitError
+}
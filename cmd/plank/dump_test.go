@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	in := writeFile(t, "in.plank", "let c red\nplot [1 2] | color $c\n")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"tokens", in}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "1:1\tkeyword(let)\n") || !strings.Contains(stdout.String(), "2:20\tvariable(c)\n") {
+		t.Error("Expected the tokens with their positions, got", stdout.String())
+	}
+
+	bad := writeFile(t, "bad.plank", "plot $\n")
+	stderr.Reset()
+	if code := run([]string{"tokens", bad}, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "error[P0005]") {
+		t.Error("Expected a parsing error, got", code, stderr.String())
+	}
+}
+
+func TestAst(t *testing.T) {
+	in := writeFile(t, "in.plank", "default plot | width 2\nplot [1 2] | color red\n")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"ast", in}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	var tree struct {
+		Body []struct {
+			Stmts []struct {
+				Keyword   string
+				Modifiers []struct{ Arguments []any }
+			}
+			Plots []struct{ Width float64 }
+		}
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if stmts := tree.Body[0].Stmts; len(stmts) != 2 || stmts[1].Modifiers[0].Arguments[0] != "red" || tree.Body[0].Plots != nil {
+		t.Error("Expected the statements only, got", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"ast", "--eval", in}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if plots := tree.Body[0].Plots; len(plots) != 1 || plots[0].Width != 2 {
+		t.Error("Expected the plot after the default, got", stdout.String())
+	}
+
+	bad := writeFile(t, "bad.plank", "plot [1 2] | colr red\n")
+	if code := run([]string{"ast", bad}, &stdout, &stderr); code != exitOK {
+		t.Error("Expected", exitOK, "without evaluation, got", code, stderr.String())
+	}
+	if code := run([]string{"ast", "-eval", bad}, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "error[E0002]") {
+		t.Error("Expected an evaluation error, got", code, stderr.String())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"io"
+	"strings"
+)
+
+var explainCommand = &command{
+	name:    "explain",
+	usage:   "explain [CODE]",
+	summary: "explain an error code, or list the codes",
+}
+
+func init() {
+	explainCommand.run = runExplain
+}
+
+func runExplain(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(explainCommand, stderr)
+	codes, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	switch len(codes) {
+	case 0:
+		for _, e := range parser.Explanations() {
+			fmt.Fprintf(stdout, "%s  %s\n", e.Code, e.Title)
+		}
+		return exitOK
+	case 1:
+	default:
+		fs.Usage()
+		return exitUsage
+	}
+
+	e, ok := parser.Explain(codes[0])
+	if !ok {
+		fmt.Fprintf(stderr, "plank: unknown error code %q, run \"plank explain\" for the list of codes\n", codes[0])
+		return exitError
+	}
+	fmt.Fprintf(stdout, "%s: %s\n\n%s\n", e.Code, e.Title, e.Text)
+	if e.Wrong != "" {
+		fmt.Fprintf(stdout, "\nFor example, this source gives %s:\n\n%s\nIt is corrected as:\n\n%s", e.Code, indent(e.Wrong), indent(e.Fixed))
+	}
+	if e.Help != "" {
+		fmt.Fprintf(stdout, "\nHelp: %s.\n", e.Help)
+	}
+	return exitOK
+}
+
+// indent returns the lines of s indented by four spaces.
+func indent(s string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		b.WriteString("    " + line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"explain", "p0003"}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "P0003: unclosed container\n") || !strings.Contains(stdout.String(), "    plot [1 2 3]\n") {
+		t.Error("Expected the explanation of P0003, got", stdout.String())
+	}
+
+	stdout.Reset()
+	run([]string{"explain"}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "E0002  unknown modifier\n") {
+		t.Error("Expected the list of the codes, got", stdout.String())
+	}
+
+	if code := run([]string{"explain", "X1"}, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), `unknown error code "X1"`) {
+		t.Error("Expected an unknown code, got", code, stderr.String())
+	}
+
+	in := writeFile(t, "in.plank", "plot [1 2\n")
+	stderr.Reset()
+	run([]string{"render", in, "-o", in + ".svg"}, &stdout, &stderr)
+	if !strings.Contains(stderr.String(), `= help: run "plank explain P0003" for more information`) {
+		t.Error("Expected a pointer to plank explain, got", stderr.String())
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/planklang/goplank/format"
+	"github.com/planklang/goplank/token"
+	"io"
+	"os"
+)
+
+var fmtCommand = &command{
+	name:    "fmt",
+	usage:   "fmt [-check | -w] file.plank|directory...",
+	summary: "print files in the canonical form",
+}
+
+func init() {
+	fmtCommand.run = runFmt
+}
+
+func runFmt(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(fmtCommand, stderr)
+	check := fs.Bool("check", false, "list the files which are not formatted instead of printing them, and fail if any")
+	write := fs.Bool("w", false, "write the result to the files instead of printing it")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(paths) == 0 || (*check && *write) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	files, err := plankFiles(paths)
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	code := exitOK
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "plank: %s\n", err)
+			code = exitError
+			continue
+		}
+		res, err := format.Source(src)
+		if err != nil {
+			sources := token.NewFileSet()
+			sources.AddFile("", string(src)) // the errors of format.Source have no file name
+			printError(stderr, sources, file, err)
+			code = exitError
+			continue
+		}
+
+		switch {
+		case *check:
+			if !bytes.Equal(src, res) {
+				fmt.Fprintln(stdout, file)
+				code = exitError
+			}
+		case *write:
+			if bytes.Equal(src, res) {
+				continue
+			}
+			if err = os.WriteFile(file, res, 0o644); err != nil {
+				fmt.Fprintf(stderr, "plank: %s\n", err)
+				code = exitError
+			}
+		default:
+			stdout.Write(res)
+		}
+	}
+	return code
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFmt(t *testing.T) {
+	path := writeFile(t, "in.plank", "axis x ;; ow plot | color red\n")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"fmt", path}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	if stdout.String() != "axis x\noverwrite plot | color red\n" {
+		t.Error("Expected the formatted source, got", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"fmt", "-check", path}, &stdout, &stderr); code != exitError {
+		t.Error("Expected", exitError, "got", code)
+	}
+	if strings.TrimSpace(stdout.String()) != path {
+		t.Error("Expected", path, "got", stdout.String())
+	}
+
+	if code := run([]string{"fmt", "-w", path}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := run([]string{"fmt", "-check", path}, &stdout, &stderr); code != exitOK || stdout.Len() != 0 {
+		t.Error("Expected", exitOK, "got", code, stdout.String())
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "axis x\noverwrite plot | color red\n" {
+		t.Error("Expected the formatted file, got", string(b))
+	}
+
+	stderr.Reset()
+	bad := writeFile(t, "bad.plank", "plot [1 2\n")
+	if code := run([]string{"fmt", "-check", bad}, &stdout, &stderr); code != exitError {
+		t.Error("Expected", exitError, "got", code)
+	}
+	if !strings.Contains(stderr.String(), "error[P0003]: unclosed container\n --> "+bad+":1:10") {
+		t.Error("Expected a parsing error, got", stderr.String())
+	}
+}
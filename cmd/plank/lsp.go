@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"github.com/planklang/goplank/lsp"
+	"io"
+	"os"
+)
+
+var lspCommand = &command{
+	name:    "lsp",
+	usage:   "lsp",
+	summary: "run the language server, speaking the Language Server Protocol over stdin and stdout",
+}
+
+func init() {
+	lspCommand.run = runLsp
+}
+
+func runLsp(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(lspCommand, stderr)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(rest) != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if err = lsp.NewServer(os.Stdin, stdout).Serve(); err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content in a temporary file and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != exitUsage {
+		t.Error("Expected", exitUsage, "got", code)
+	}
+	if code := run([]string{"unknown"}, &stdout, &stderr); code != exitUsage {
+		t.Error("Expected", exitUsage, "got", code)
+	}
+	if code := run([]string{"help"}, &stdout, &stderr); code != exitOK {
+		t.Error("Expected", exitOK, "got", code)
+	}
+	if !strings.Contains(stdout.String(), "render") {
+		t.Error("Expected the list of commands, got", stdout.String())
+	}
+}
+
+func TestRender(t *testing.T) {
+	in := writeFile(t, "in.plank", "plot [1 2 3] 'a'\n---\nplot [4 5 6] 'b'\n")
+	out := filepath.Join(filepath.Dir(in), "out.py")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"render", in, "-o", out, "-figure", "2"}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `label="b"`) || strings.Contains(string(b), `label="a"`) {
+		t.Error("Expected only the second figure, got", string(b))
+	}
+
+	stdout.Reset()
+	if code := run([]string{"render", "-format", "vegalite", in}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "vega-lite") {
+		t.Error("Expected a Vega-Lite specification, got", stdout.String())
+	}
+
+	for _, args := range [][]string{
+		{"render", in, "-o", "out.unknown"},
+		{"render", in, "-figure", "3"},
+		{"render"},
+	} {
+		if code := run(args, &stdout, &stderr); code != exitUsage {
+			t.Error("Expected", exitUsage, "for", args, "got", code)
+		}
+	}
+}
+
+func TestRenderWarnings(t *testing.T) {
+	in := writeFile(t, "in.plank", "let xs [1 2]\nplot [1 2 3]\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"render", in}, &stdout, &stderr); code != exitOK {
+		t.Fatal("Expected", exitOK, "got", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "warning[W0001]: unused variable") || !strings.Contains(stderr.String(), "--> "+in+":1:1") {
+		t.Error("Expected the unused variable, got", stderr.String())
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	in := writeFile(t, "in.plank", "plot [1 2] | colr red\n")
+	out := filepath.Join(filepath.Dir(in), "out.svg")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"render", in, "-o", out}, &stdout, &stderr); code != exitError {
+		t.Fatal("Expected", exitError, "got", code)
+	}
+	if !strings.Contains(stderr.String(), "error[E0002]: unknown modifier") || !strings.Contains(stderr.String(), "1 | plot [1 2] | colr red\n  |              ^^^^ ") {
+		t.Error("Expected a formatted diagnostic, got", stderr.String())
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("Expected no output file")
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	for _, c := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"a", "-v", "b"}, "a b"},
+		{[]string{"-v", "--", "-o", "a"}, "-o a"},
+		{[]string{"a", "--", "-v", "--"}, "a -v --"},
+		{[]string{"-o", "--", "a"}, "a"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Bool("v", false, "")
+		fs.String("o", "", "")
+		args, err := parseArgs(fs, c.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(args, " ") != c.expected {
+			t.Error("Expected", c.expected, "for", c.args, "got", args)
+		}
+	}
+}
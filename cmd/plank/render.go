@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/planklang/goplank"
+	"github.com/planklang/goplank/render"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var renderCommand = &command{
+	name:    "render",
+	usage:   "render [-o output] [-format name] [-figure list] file.plank",
+	summary: "render the figures of a file",
+}
+
+func init() {
+	renderCommand.run = runRender
+}
+
+func runRender(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(renderCommand, stderr)
+	output := fs.String("o", "-", "output file, - for the standard output")
+	format := fs.String("format", "", "output format, guessed from the output file by default ("+strings.Join(render.Backends(), ", ")+")")
+	figures := fs.String("figure", "", "comma-separated list of the figures to render, starting at 1 (all by default)")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	backend, err := backendFor(*format, *output)
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitUsage
+	}
+
+	return renderFile(backend, files[0], *figures, *output, stdout, stderr)
+}
+
+// renderFile renders the figures of the file at path listed in figures to output with backend, printing the problems
+// to stderr. It returns the exit code of the render command.
+func renderFile(backend, path, figures, output string, stdout, stderr io.Writer) int {
+	files := token.NewFileSet()
+	doc, err := compileFile(vfs.OS(), path, files, goplank.Limits{})
+	if err != nil {
+		printError(stderr, files, path, err)
+		return exitError
+	}
+	for _, w := range doc.Warnings() {
+		printWarning(stderr, files, path, w)
+	}
+	opts := new(goplank.RenderOptions)
+	if opts.Figures, err = figureIndexes(figures, len(doc.Figures())); err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitUsage
+	}
+
+	var buf bytes.Buffer // nothing is written when rendering fails
+	warns, err := doc.Render(&buf, backend, opts)
+	for _, w := range warns {
+		fmt.Fprintf(stderr, "%s: warning: %s\n", path, w)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	if err = writeOutput(output, buf.Bytes(), stdout); err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// backendFor returns the name of the backend named format, or of the one of the output file.
+func backendFor(format, output string) (string, error) {
+	if format == "" {
+		if output == "-" {
+			return "svg", nil
+		}
+		name, err := render.ForFile(output)
+		if err != nil {
+			return "", fmt.Errorf("%w, choose one with -format", err)
+		}
+		return name, nil
+	}
+	if _, err := render.Lookup(format); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// figureIndexes returns the indexes, starting at 0, of the figures listed in list, e.g. "1,3", of a document of n
+// figures. It returns no index when list is empty.
+func figureIndexes(list string, n int) ([]int, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var indexes []int
+	for _, s := range strings.Split(list, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || i < 1 || i > n {
+			return nil, fmt.Errorf("invalid figure %q, the file has %d figures", s, n)
+		}
+		indexes = append(indexes, i-1)
+	}
+	return indexes, nil
+}
+
+func writeOutput(output string, b []byte, stdout io.Writer) error {
+	if output == "-" {
+		_, err := stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(output, b, 0o644)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/planklang/goplank"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+)
+
+var replCommand = &command{
+	name:    "repl",
+	usage:   "repl [-o output] [-format name]",
+	summary: "enter statements one at a time, rendering the current figure after each",
+}
+
+func init() {
+	replCommand.run = runRepl
+}
+
+const replHelp = `Enter statements, one or several separated by ;; on a line. A line --- starts a new figure.
+Variables, defaults and figures are kept until :reset.
+
+Commands:
+  :tokens [statement]  print the tokens of the statement, or of the last input
+  :ast [statement]     print the syntax tree of the statement, or of the session
+  :reset               forget every input
+  :help                print this help
+  :quit                leave, like Ctrl-D
+`
+
+func runRepl(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(replCommand, stderr)
+	output := fs.String("o", "", "output file, the terminal by default")
+	format := fs.String("format", "", "output format, guessed from the output file by default ("+strings.Join(render.Backends(), ", ")+")")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(rest) != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	r := &repl{output: *output, stdout: stdout, stderr: stderr}
+	if *output == "" && *format == "" {
+		r.backend = "text"
+	} else if *output == "" {
+		r.backend, err = backendFor(*format, "-")
+	} else {
+		r.backend, err = backendFor(*format, *output)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitUsage
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		r.loop(&scanner{bufio.NewScanner(os.Stdin)})
+		return exitOK
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	defer term.Restore(fd, state)
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, stdout}, "plank> ")
+	r.stdout, r.stderr = t, t // the terminal writes \r\n in raw mode
+	fmt.Fprintln(t, "plank repl, :help for help")
+	r.loop(t)
+	return exitOK
+}
+
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+// scanner reads lines when the input is not a terminal.
+type scanner struct {
+	*bufio.Scanner
+}
+
+func (s *scanner) ReadLine() (string, error) {
+	if !s.Scan() {
+		if s.Err() != nil {
+			return "", s.Err()
+		}
+		return "", io.EOF
+	}
+	return s.Text(), nil
+}
+
+// repl holds the state of a session: the inputs accepted until now, whose source is compiled again with each new
+// input, so that the variables, the defaults and the figures are kept.
+type repl struct {
+	inputs  []string
+	backend string
+	output  string // empty for stdout
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+func (r *repl) loop(lines lineReader) {
+	for {
+		line, err := lines.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(r.stderr, "plank: %s\n", err)
+			}
+			return
+		}
+		if !r.eval(line) {
+			return
+		}
+	}
+}
+
+// source returns the source of the session followed by line.
+func (r *repl) source(line string) string {
+	return strings.Join(append(r.inputs[:len(r.inputs):len(r.inputs)], line), "\n")
+}
+
+// eval runs a line, a command or statements, and returns false to leave.
+func (r *repl) eval(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+	if strings.HasPrefix(line, ":") {
+		return r.command(line)
+	}
+
+	src := r.source(line)
+	sources := token.NewFileSet()
+	doc, err := goplank.Compile(src, &goplank.Options{FS: vfs.OS(), FileSet: sources}) // its includes stay relative to the working directory
+	if err != nil {
+		printError(r.stderr, sources, "input", err)
+		return true
+	}
+	r.inputs = append(r.inputs, line)
+
+	figs := doc.Figures()
+	if len(figs[len(figs)-1].Plots) == 0 {
+		return true
+	}
+	var buf bytes.Buffer
+	warns, err := doc.Render(&buf, r.backend, &goplank.RenderOptions{Figures: []int{len(figs) - 1}})
+	for _, w := range warns {
+		fmt.Fprintf(r.stderr, "warning: %s\n", w.Message)
+	}
+	if err != nil {
+		fmt.Fprintf(r.stderr, "plank: %s\n", err)
+		return true
+	}
+	if r.output == "" {
+		r.stdout.Write(buf.Bytes())
+		return true
+	}
+	if err = writeOutput(r.output, buf.Bytes(), r.stdout); err != nil {
+		fmt.Fprintf(r.stderr, "plank: %s\n", err)
+		return true
+	}
+	fmt.Fprintf(r.stdout, "figure %d rendered to %s\n", len(figs), r.output)
+	return true
+}
+
+func (r *repl) command(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		fmt.Fprint(r.stdout, replHelp)
+	case ":reset":
+		r.inputs = nil
+	case ":tokens":
+		if arg == "" && len(r.inputs) > 0 {
+			arg = r.inputs[len(r.inputs)-1]
+		}
+		sources := token.NewFileSet()
+		lex, err := (&lexer.Config{FileSet: sources}).Lex(context.Background(), arg)
+		if err != nil {
+			printError(r.stderr, sources, "input", err)
+			return true
+		}
+		writeTokens(r.stdout, lex)
+	case ":ast":
+		src := strings.Join(r.inputs, "\n")
+		if arg != "" {
+			src = r.source(arg)
+		}
+		sources := token.NewFileSet()
+		lex, err := (&lexer.Config{FileSet: sources}).Lex(context.Background(), src)
+		if err == nil {
+			var tree *parser.Ast
+			if tree, err = (&parser.Config{FS: vfs.OS()}).Parse(context.Background(), lex); err == nil {
+				if arg != "" { // only the statement, parsed after the session for its variables
+					stmts := tree.Body[len(tree.Body)-1].Stmts
+					if len(stmts) > 0 {
+						stmts = stmts[len(stmts)-1:]
+					}
+					tree = &parser.Ast{Type: tree.Type, Body: []*parser.Figure{{Stmts: stmts}}}
+				}
+				fmt.Fprintln(r.stdout, tree)
+				return true
+			}
+		}
+		printError(r.stderr, sources, "input", err)
+	default:
+		fmt.Fprintf(r.stderr, "unknown command %s, :help for help\n", name)
+	}
+	return true
+}
+
+// writeTokens writes a token per line, with its position starting at 1.
+func writeTokens(w io.Writer, lex *lexer.TokenList) {
+	for _, t := range lex.Tokens() {
+		pos := lex.Position(t.Pos)
+		fmt.Fprintf(w, "%d:%d\t%s\n", pos.Line+1, pos.Column+1, t)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	r := &repl{backend: "text", stdout: &stdout, stderr: &stderr}
+	r.loop(&scanner{bufio.NewScanner(strings.NewReader("let xs [1 2 3]\ndefault plot | style scatter\nplot $xs 'data'\n"))})
+	if stderr.Len() != 0 {
+		t.Fatal("Unexpected error", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "  * data\n") {
+		t.Error("Expected the figure to be rendered, got", stdout.String())
+	}
+
+	stdout.Reset()
+	r.eval("plot $ys")
+	if !strings.Contains(stderr.String(), "undefined variable") || len(r.inputs) != 3 {
+		t.Error("Expected the input to be rejected, got", stderr.String(), r.inputs)
+	}
+
+	stdout.Reset()
+	r.eval(":tokens")
+	if !strings.HasPrefix(stdout.String(), "1:1\tkeyword(plot)\n1:6\tvariable(xs)\n") {
+		t.Error("Expected the tokens of the last input, got", stdout.String())
+	}
+	stdout.Reset()
+	r.eval(":ast plot $xs")
+	if !strings.Contains(stdout.String(), `"Name": "xs"`) {
+		t.Error("Expected the tree of the statement, got", stdout.String())
+	}
+
+	r.eval(":reset")
+	if len(r.inputs) != 0 {
+		t.Error("Expected no input, got", r.inputs)
+	}
+	if r.eval(":quit") {
+		t.Error("Expected :quit to leave")
+	}
+}
+
+func TestReplOutput(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.svg")
+	svg, err := backendFor("", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	r := &repl{backend: svg, output: output, stdout: &stdout, stderr: &stderr}
+	r.eval("plot [1 2]")
+	r.eval("---")
+	r.eval("plot [3 4] 'second'")
+	if stdout.String() != "figure 1 rendered to "+output+"\nfigure 2 rendered to "+output+"\n" {
+		t.Error("Expected 2 renders, got", stdout.String(), stderr.String())
+	}
+	b, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte("second")) {
+		t.Error("Expected the current figure, got", string(b))
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank"
+	"github.com/planklang/goplank/render"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var serveCommand = &command{
+	name:    "serve",
+	usage:   "serve [-addr address] [-interval duration] directory",
+	summary: "serve the rendered files of a directory, reloaded when they change",
+}
+
+func init() {
+	serveCommand.run = runServe
+}
+
+func runServe(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(serveCommand, stderr)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	interval := fs.Duration("interval", 500*time.Millisecond, "time between two checks of a file shown in a browser")
+	dirs, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(dirs) != 1 || *interval <= 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	root, err := vfs.Root(dirs[0]) // the files cannot include the files outside the directory
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s is not a directory\n", dirs[0])
+		return exitError
+	}
+	defer root.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	srv := &http.Server{Addr: *addr, Handler: newServer(dirs[0], root, *interval)}
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	fmt.Fprintf(stdout, "serving %s on http://%s\n", dirs[0], *addr)
+	if err = srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// server serves the .plank files of a directory:
+//
+//	/                    lists the files
+//	/view/{file}         shows the rendered file, or its diagnostic, and reloads when it changes
+//	/render/{file}       renders the file with the backend of the format parameter, svg by default
+//	/events/{file}       sends a reload event each time the file changes
+type server struct {
+	dir      string
+	files    fs.FS // the files of dir, read by the compiler
+	interval time.Duration
+}
+
+// serveLimits bounds the resources used by a request, so that a file of the directory cannot exhaust the server.
+var serveLimits = goplank.Limits{
+	MaxSourceSize: 1 << 20,
+	MaxTokens:     1 << 20,
+	MaxDepth:      64,
+	MaxPoints:     1 << 20,
+	MaxOutputSize: 64 << 20,
+}
+
+func newServer(dir string, files fs.FS, interval time.Duration) http.Handler {
+	s := &server{dir: dir, files: files, interval: interval}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.index)
+	mux.HandleFunc("GET /view/{file...}", s.view)
+	mux.HandleFunc("GET /render/{file...}", s.render)
+	mux.HandleFunc("GET /events/{file...}", s.events)
+	return mux
+}
+
+// file returns the name in s.files of the file of the request, or an empty string if it is not a .plank file of the
+// directory.
+func (s *server) file(r *http.Request) string {
+	name := r.PathValue("file")
+	if !fs.ValidPath(name) || path.Ext(name) != ".plank" {
+		return ""
+	}
+	return name
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>plank: {{.Dir}}</title></head>
+<body>
+<h1>{{.Dir}}</h1>
+<ul>
+{{range .Files}}<li><a href="/view/{{.}}">{{.}}</a></li>
+{{else}}<li>no .plank file</li>
+{{end}}</ul>
+</body></html>
+`))
+
+func (s *server) index(w http.ResponseWriter, r *http.Request) {
+	paths, err := plankFiles([]string{s.dir})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var files []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			continue
+		}
+		files = append(files, filepath.ToSlash(rel))
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	indexTemplate.Execute(w, struct {
+		Dir   string
+		Files []string
+	}{s.dir, files})
+}
+
+var viewTemplate = template.Must(template.New("view").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>plank: {{.File}}</title>
+<style>
+body { font-family: sans-serif; }
+.overlay { position: fixed; inset: 0; overflow: auto; background: rgba(20, 20, 20, 0.92); color: #eee; padding: 2em; }
+.overlay pre { color: #ff8080; font-size: 14px; white-space: pre-wrap; }
+.warning { color: #a06000; }
+</style></head>
+<body>
+<p><a href="/">index</a> / {{.File}}</p>
+{{if .Error}}<div class="overlay"><pre>{{.Error}}</pre></div>
+{{else}}{{.Figure}}
+{{range .Lints}}<pre class="warning">{{.}}</pre>
+{{end}}{{range .Warnings}}<p class="warning">warning: {{.}}</p>
+{{end}}{{end}}<script>
+new EventSource("/events/{{.File}}").addEventListener("reload", () => location.reload());
+</script>
+</body></html>
+`))
+
+func (s *server) view(w http.ResponseWriter, r *http.Request) {
+	name := s.file(r)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	data := struct {
+		File     string
+		Figure   template.HTML
+		Error    string
+		Lints    []string // the warnings of the document
+		Warnings []render.Warning
+	}{File: name}
+
+	sources := token.NewFileSet()
+	doc, err := compileFile(s.files, name, sources, serveLimits)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err == nil {
+		for _, w := range doc.Warnings() {
+			var b strings.Builder
+			printWarning(&b, sources, name, w)
+			data.Lints = append(data.Lints, b.String())
+		}
+		var buf bytes.Buffer
+		data.Warnings, err = doc.RenderContext(r.Context(), &buf, "svg", nil)
+		data.Figure = template.HTML(buf.String()) // generated by the svg backend, which escapes the texts
+	}
+	if err != nil {
+		data.Error = formatError(sources, data.File, err)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	viewTemplate.Execute(w, data)
+}
+
+func (s *server) render(w http.ResponseWriter, r *http.Request) {
+	name := s.file(r)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "svg"
+	}
+	if _, err := render.Lookup(format); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sources := token.NewFileSet()
+	doc, err := compileFile(s.files, name, sources, serveLimits)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, formatError(sources, name, err), http.StatusUnprocessableEntity)
+		return
+	}
+	var buf bytes.Buffer
+	if _, err = doc.RenderContext(r.Context(), &buf, format, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if format == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Write(buf.Bytes())
+}
+
+func (s *server) events(w http.ResponseWriter, r *http.Request) {
+	name := s.file(r)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	wt := &watcher{
+		files:    s.files,
+		name:     name,
+		interval: s.interval,
+		debounce: s.interval,
+		build: func() {
+			fmt.Fprint(w, "event: reload\ndata: reload\n\n")
+			flusher.Flush()
+		},
+	}
+	wt.run(r.Context())
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"github.com/planklang/goplank/vfs"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"ok.plank":      "plot [1 2 3] \"data\"\n",
+		"sub/bad.plank": "plot [1 2] | colr red\n",
+		"escape.plank":  "include \"/etc/hostname\"\n",
+		"lint.plank":    "let xs [1 2]\nplot [1 2 3]\n",
+		"deep.plank":    "plot " + strings.Repeat("[", 100) + "1" + strings.Repeat("]", 100) + "\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := vfs.Root(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+	ts := httptest.NewServer(newServer(dir, root, 5*time.Millisecond))
+	defer ts.Close()
+
+	get := func(path string) (int, string, string) {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, res.Header.Get("Content-Type"), string(b)
+	}
+
+	if code, _, body := get("/"); code != http.StatusOK || !strings.Contains(body, `href="/view/ok.plank"`) || !strings.Contains(body, `href="/view/sub/bad.plank"`) {
+		t.Error("Expected the list of the files, got", code, body)
+	}
+	if code, _, body := get("/view/ok.plank"); code != http.StatusOK || !strings.Contains(body, "<svg") || strings.Contains(body, "overlay\"") {
+		t.Error("Expected the rendered figure, got", code, body)
+	}
+	if code, _, body := get("/view/lint.plank"); code != http.StatusOK || !strings.Contains(body, "<svg") || !strings.Contains(body, "warning[W0001]: unused variable") {
+		t.Error("Expected the figure and its warnings, got", code, body)
+	}
+	if code, _, body := get("/view/sub/bad.plank"); code != http.StatusOK || !strings.Contains(body, `<div class="overlay">`) || !strings.Contains(body, "sub/bad.plank:1:14") {
+		t.Error("Expected the error overlay, got", code, body)
+	}
+	if code, typ, body := get("/render/ok.plank"); code != http.StatusOK || typ != "image/svg+xml" || !strings.HasPrefix(body, "<svg") {
+		t.Error("Expected an svg, got", code, typ, body)
+	}
+	if code, _, body := get("/render/ok.plank?format=gnuplot"); code != http.StatusOK || !strings.Contains(body, "plot $figure1_plot1") {
+		t.Error("Expected a gnuplot script, got", code, body)
+	}
+	if code, _, _ := get("/render/sub/bad.plank"); code != http.StatusUnprocessableEntity {
+		t.Error("Expected", http.StatusUnprocessableEntity, "got", code)
+	}
+	if code, _, body := get("/render/escape.plank"); code != http.StatusUnprocessableEntity || !strings.Contains(body, vfs.ErrPathEscapes.Error()) {
+		t.Error("Expected the files outside the directory to be rejected, got", code, body)
+	}
+	if code, _, body := get("/render/deep.plank"); code != http.StatusUnprocessableEntity || !strings.Contains(body, "more than 64") {
+		t.Error("Expected the limits of the server, got", code, body)
+	}
+	for _, path := range []string{"/view/missing.plank", "/view/sub/../../secret.plank", "/render/ok.txt"} {
+		if code, _, _ := get(path); code != http.StatusNotFound {
+			t.Error("Expected", http.StatusNotFound, "for", path, "got", code)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events/ok.plank", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if typ := res.Header.Get("Content-Type"); typ != "text/event-stream" {
+		t.Error("Expected text/event-stream, got", typ)
+	}
+	lines := bufio.NewScanner(res.Body)
+	lines.Scan() // the stream is open once the comment is received
+	if err := os.WriteFile(filepath.Join(dir, "ok.plank"), []byte("plot [1 2 3 4]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for lines.Scan() {
+		if lines.Text() == "event: reload" {
+			return
+		}
+	}
+	t.Error("Expected a reload event, got", lines.Err())
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"github.com/planklang/goplank/vfs"
+	"io"
+	"io/fs"
+	"iter"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+var watchCommand = &command{
+	name:    "watch",
+	usage:   "watch -o output [-format name] [-figure list] [-interval duration] [-debounce duration] file.plank",
+	summary: "render a file again each time it changes",
+}
+
+func init() {
+	watchCommand.run = runWatch
+}
+
+func runWatch(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(watchCommand, stderr)
+	output := fs.String("o", "", "output file")
+	format := fs.String("format", "", "output format, guessed from the output file by default ("+strings.Join(render.Backends(), ", ")+")")
+	figures := fs.String("figure", "", "comma-separated list of the figures to render, starting at 1 (all by default)")
+	interval := fs.Duration("interval", 500*time.Millisecond, "time between two checks of the files")
+	debounce := fs.Duration("debounce", 200*time.Millisecond, "time the files must stay unchanged before rendering")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(files) != 1 || *output == "" || *interval <= 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	backend, err := backendFor(*format, *output)
+	if err != nil {
+		fmt.Fprintf(stderr, "plank: %s\n", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w := &watcher{
+		files:    vfs.OS(),
+		name:     filepath.ToSlash(files[0]),
+		interval: *interval,
+		debounce: *debounce,
+		build: func() {
+			if renderFile(backend, files[0], *figures, *output, stdout, stderr) == exitOK {
+				fmt.Fprintf(stdout, "%s: rendered %s\n", time.Now().Format(time.TimeOnly), *output)
+			}
+		},
+	}
+	w.build()
+	w.run(ctx)
+	return exitOK
+}
+
+// stamp identifies a version of a file.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher polls a source of files and the files it depends on, and builds it again when they change.
+type watcher struct {
+	files    fs.FS
+	name     string
+	interval time.Duration
+	debounce time.Duration
+	build    func()
+}
+
+// dependencies returns the files read when compiling the source: the source and the files it includes, also when
+// they have errors, so that fixing them builds the source again.
+func (w *watcher) dependencies() []string {
+	var deps []string
+	var visit func(name string)
+	visit = func(name string) {
+		if slices.Contains(deps, name) { // an include cycle, reported by the build
+			return
+		}
+		deps = append(deps, name)
+		b, err := fs.ReadFile(w.files, name)
+		if err != nil {
+			return
+		}
+		lex, err := (&lexer.Config{File: name}).Lex(context.Background(), string(b))
+		if err != nil {
+			return
+		}
+		for _, include := range parser.Includes(lex) {
+			visit(include)
+		}
+	}
+	visit(w.name)
+	return deps
+}
+
+// snapshot returns the stamps of the dependencies, the zero stamp for the missing ones. The dependencies are the ones
+// of last when none of them changed, so that polling unchanged files does not read them.
+func (w *watcher) snapshot(last map[string]stamp) map[string]stamp {
+	if last != nil {
+		if stamps := w.stat(maps.Keys(last)); maps.Equal(stamps, last) {
+			return stamps
+		}
+	}
+	return w.stat(slices.Values(w.dependencies()))
+}
+
+// stat returns the stamps of the files names.
+func (w *watcher) stat(names iter.Seq[string]) map[string]stamp {
+	stamps := make(map[string]stamp)
+	for name := range names {
+		var s stamp
+		if info, err := fs.Stat(w.files, name); err == nil {
+			s = stamp{info.ModTime(), info.Size()}
+		}
+		stamps[name] = s
+	}
+	return stamps
+}
+
+// run builds the source after each change until ctx is done.
+// A change is built once the files did not change for the debounce duration, so that saving several files at once
+// builds them once.
+func (w *watcher) run(ctx context.Context) {
+	last := w.snapshot(nil)
+	var changed time.Time // zero when everything is built
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if cur := w.snapshot(last); !maps.Equal(cur, last) {
+			last = cur
+			changed = time.Now()
+			continue
+		}
+		if !changed.IsZero() && time.Since(changed) >= w.debounce {
+			changed = time.Time{}
+			w.build()
+			last = w.snapshot(nil) // the dependencies may have changed
+		}
+	}
+}
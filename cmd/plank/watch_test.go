@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/planklang/goplank/vfs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	path := writeFile(t, "in.plank", "plot [1 2 3]\n")
+	output := filepath.Join(filepath.Dir(path), "out.svg")
+	backend, err := backendFor("", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var stdout, stderr bytes.Buffer
+	builds := 0
+	w := &watcher{
+		files:    vfs.OS(),
+		name:     filepath.ToSlash(path),
+		interval: 5 * time.Millisecond,
+		debounce: 20 * time.Millisecond,
+		build: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			builds++
+			renderFile(backend, path, "", output, &stdout, &stderr)
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.build()
+		w.run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// wait returns once the build count reaches n
+	wait := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			b := builds
+			mu.Unlock()
+			if b >= n {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("Expected", n, "builds, got", builds)
+	}
+
+	wait(1)
+	if _, err := os.Stat(output); err != nil {
+		t.Fatal("Expected the output to be rendered, got", err)
+	}
+
+	if err := os.WriteFile(path, []byte("plot [1 2 3] | colr red\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wait(2)
+	mu.Lock()
+	if !strings.Contains(stderr.String(), "--> "+path+":1:16") {
+		t.Error("Expected an evaluation error, got", stderr.String())
+	}
+	mu.Unlock()
+
+	if err := os.WriteFile(path, []byte("plot [1 2 3] \"fixed\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wait(3)
+	b, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte("fixed")) {
+		t.Error("Expected the output to be rendered again")
+	}
+}
+
+func TestWatchDependencies(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"in.plank":           "include \"style/common.plank\"\nplot [1 2]\n",
+		"style/common.plank": "include \"colors.plank\"\ninclude \"../in.plank\"\n",
+		"style/colors.plank": "let c (1 2\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := vfs.Root(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+	w := &watcher{files: root, name: "in.plank"}
+	deps := w.dependencies()
+	if len(deps) != 3 || deps[1] != "style/common.plank" || deps[2] != "style/colors.plank" {
+		t.Error("Expected the included files, also with errors, got", deps)
+	}
+}
+
+// readCounter counts the files read from its file system.
+type readCounter struct {
+	fstest.MapFS
+	reads int
+}
+
+func (c *readCounter) ReadFile(name string) ([]byte, error) {
+	c.reads++
+	return c.MapFS.ReadFile(name)
+}
+
+func TestWatchSnapshot(t *testing.T) {
+	files := &readCounter{MapFS: fstest.MapFS{
+		"in.plank":     {Data: []byte("include \"style.plank\"\nplot [1 2]\n")},
+		"style.plank":  {Data: []byte("let c 1\n")},
+		"colors.plank": {Data: []byte("let c 2\n")},
+	}}
+	w := &watcher{files: files, name: "in.plank"}
+	last := w.snapshot(nil)
+	if len(last) != 2 || files.reads != 2 {
+		t.Error("Expected the 2 dependencies to be read, got", last, files.reads)
+	}
+	files.reads = 0
+	if cur := w.snapshot(last); !maps.Equal(cur, last) || files.reads != 0 {
+		t.Error("Expected the unchanged files not to be read, got", files.reads)
+	}
+	files.MapFS["style.plank"] = &fstest.MapFile{Data: []byte("include \"colors.plank\"\n")}
+	if cur := w.snapshot(last); len(cur) != 3 || files.reads != 3 {
+		t.Error("Expected the dependencies to be found again, got", cur, files.reads)
+	}
+}
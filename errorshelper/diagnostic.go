@@ -0,0 +1,251 @@
+package errorshelper
+
+import (
+	"fmt"
+	"github.com/planklang/goplank/token"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Span is a part of a source, from Line and Column included to EndLine and EndColumn excluded.
+// Lines and columns start at 0, columns are in bytes.
+type Span struct {
+	File      string // the name of the source in its file set, empty when it has none
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// Label is a message about a span. The primary label tells where the error is, the secondary ones give context.
+type Label struct {
+	Span
+	Message string
+	Primary bool
+}
+
+// Suggestion is a fix of a diagnostic, replacing a span of the source.
+type Suggestion struct {
+	Span
+	Replacement string
+}
+
+// Diagnostic is an error or a warning with its code, the spans it is about and help notes.
+type Diagnostic struct {
+	Severity    Severity
+	Code        string // e.g. P0003, may be empty
+	Message     string
+	Labels      []Label
+	Help        []string
+	Suggestions []Suggestion // shown in the help notes, and applied by editors
+}
+
+// Primary returns the primary label of d, and false if it has none.
+func (d *Diagnostic) Primary() (Label, bool) {
+	for _, l := range d.Labels {
+		if l.Primary {
+			return l, true
+		}
+	}
+	return Label{}, false
+}
+
+// ANSI escape codes used when rendering with color.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiBlue   = "\x1b[1;34m"
+	ansiCyan   = "\x1b[1;36m"
+)
+
+// Render writes d about the file at path containing src, like:
+//
+//	error[P0003]: unclosed container
+//	 --> figure.plank:1:6
+//	  |
+//	1 | plot [1 2
+//	  |      ^ missing ]
+//	  |
+//	  = help: close the container on the same line
+//
+// ANSI colors are used if color is true.
+func (d *Diagnostic) Render(w io.Writer, path, src string, color bool) error {
+	p := &printer{color: color}
+	sevColor := ansiRed
+	if d.Severity == SeverityWarning {
+		sevColor = ansiYellow
+	}
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+	p.paint(sevColor, header)
+	p.paint(ansiBold, ": "+d.Message)
+	p.WriteByte('\n')
+
+	lines := strings.Split(src, "\n")
+	labels := slices.DeleteFunc(slices.Clone(d.Labels), func(l Label) bool { return l.Line >= len(lines) })
+	for i := range labels { // spans ending before their start, like those without end, are one character long
+		l := &labels[i]
+		if l.EndLine < l.Line || (l.EndLine == l.Line && l.EndColumn <= l.Column) {
+			l.EndLine, l.EndColumn = l.Line, l.Column+1
+		}
+		l.EndLine = min(l.EndLine, len(lines)-1)
+	}
+	slices.SortStableFunc(labels, func(a, b Label) int { // the primary label first, then in source order
+		if a.Primary != b.Primary {
+			if a.Primary {
+				return -1
+			}
+			return 1
+		}
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+
+	var shown []int // the lines to show, in order
+	for _, l := range labels {
+		for ln := l.Line; ln <= l.EndLine; ln++ {
+			if !slices.Contains(shown, ln) {
+				shown = append(shown, ln)
+			}
+		}
+	}
+	slices.Sort(shown)
+	width := 1
+	if len(shown) > 0 {
+		width = len(strconv.Itoa(shown[len(shown)-1] + 1))
+	}
+	gutter := func(s string) {
+		p.paint(ansiBlue, fmt.Sprintf("%*s |", width, s))
+	}
+
+	if path != "" || len(labels) > 0 {
+		p.WriteString(strings.Repeat(" ", width))
+		p.paint(ansiBlue, "--> ")
+		loc := path
+		if len(labels) > 0 {
+			l := labels[0]
+			loc += fmt.Sprintf(":%d:%d", l.Line+1, displayColumn(lines[l.Line], l.Column)+1)
+		}
+		p.WriteString(strings.TrimPrefix(loc, ":") + "\n")
+	}
+	if len(shown) > 0 {
+		gutter("")
+		p.WriteByte('\n')
+	}
+	for i, ln := range shown {
+		if i > 0 && ln > shown[i-1]+1 {
+			p.paint(ansiBlue, strings.Repeat(".", width+2))
+			p.WriteByte('\n')
+		}
+		line := strings.ReplaceAll(lines[ln], "\t", " ")
+		gutter(strconv.Itoa(ln + 1))
+		if line != "" {
+			p.WriteString(" " + line)
+		}
+		p.WriteByte('\n')
+
+		var marks []Label // the labels on the line, by column, to draw each on its own line
+		for _, l := range labels {
+			if l.Line <= ln && ln <= l.EndLine {
+				marks = append(marks, l)
+			}
+		}
+		slices.SortStableFunc(marks, func(a, b Label) int { return a.startOn(ln) - b.startOn(ln) })
+		for _, l := range marks {
+			start := displayColumn(lines[ln], l.startOn(ln))
+			end := utf8.RuneCountInString(lines[ln]) + 1 // the end of the line, for multi-line spans
+			if ln == l.EndLine {
+				end = displayColumn(lines[ln], l.EndColumn)
+			}
+			mark, markColor := "-", ansiBlue
+			if l.Primary {
+				mark, markColor = "^", sevColor
+			}
+			gutter("")
+			p.WriteString(" " + strings.Repeat(" ", start))
+			text := strings.Repeat(mark, max(end-start, 1))
+			if ln == l.EndLine && l.Message != "" {
+				text += " " + l.Message
+			}
+			p.paint(markColor, text)
+			p.WriteByte('\n')
+		}
+	}
+
+	if len(shown) > 0 && len(d.Help) > 0 {
+		gutter("")
+		p.WriteByte('\n')
+	}
+	for _, h := range d.Help {
+		p.WriteString(strings.Repeat(" ", width+1))
+		p.paint(ansiCyan, "= help:")
+		p.WriteString(" " + h + "\n")
+	}
+	_, err := io.WriteString(w, p.String())
+	return err
+}
+
+// RenderFiles writes d like Render, about the file of its primary label in fset. The labels of the other files are
+// not shown.
+func (d *Diagnostic) RenderFiles(w io.Writer, fset *token.FileSet, color bool) error {
+	var name, src string
+	if l, ok := d.Primary(); ok {
+		name = l.File
+		if f := fset.Lookup(name); f != nil {
+			src = f.Source()
+		}
+	}
+	shown := *d
+	shown.Labels = slices.DeleteFunc(slices.Clone(d.Labels), func(l Label) bool { return l.File != name })
+	return shown.Render(w, name, src, color)
+}
+
+// startOn returns the byte column where the span of l starts on the line ln.
+func (l Label) startOn(ln int) int {
+	if ln == l.Line {
+		return l.Column
+	}
+	return 0
+}
+
+// displayColumn returns the number of characters before the byte column col of line.
+func displayColumn(line string, col int) int {
+	return utf8.RuneCountInString(line[:min(max(col, 0), len(line))]) + max(col-len(line), 0)
+}
+
+type printer struct {
+	strings.Builder
+	color bool
+}
+
+// paint writes s with the ANSI code c if colors are enabled.
+func (p *printer) paint(c, s string) {
+	if p.color {
+		p.WriteString(c + s + ansiReset)
+	} else {
+		p.WriteString(s)
+	}
+}
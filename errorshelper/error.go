@@ -0,0 +1,31 @@
+package errorshelper
+
+import (
+	"fmt"
+	"github.com/planklang/goplank/token"
+)
+
+// Error is an error located in a source.
+type Error struct {
+	File   string // the name of the source, empty when it has none
+	Offset int    // starting at 0, in bytes
+	Line   int    // starting at 0
+	Column int    // starting at 0, in bytes
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s: line %d, column %d: %s", e.File, e.Line+1, e.Column+1, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line+1, e.Column+1, e.Err)
+}
+
+// Position returns the position of e, printed as file:line:col.
+func (e *Error) Position() token.Position {
+	return token.Position{File: e.File, Offset: e.Offset, Line: e.Line, Column: e.Column}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
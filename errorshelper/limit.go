@@ -0,0 +1,23 @@
+package errorshelper
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitError reports a document exceeding a limit set for untrusted sources. It matches ErrLimitExceeded with
+// errors.Is.
+type LimitError struct {
+	Limit string // what is limited, e.g. "tokens"
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: more than %d %s", ErrLimitExceeded, e.Max, e.Limit)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
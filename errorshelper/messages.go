@@ -5,6 +5,9 @@ import (
 	"strings"
 )
 
+// GenErrorMessage returns the message of err pointing at the word i of the line of a source made of words.
+//
+// Deprecated: use [Diagnostic.Render], which underlines exact spans.
 func GenErrorMessage(global string, err error, i int, words []string, line int) string {
 	s := ""
 	for j := range i {
@@ -0,0 +1,55 @@
+package errorshelper
+
+import "fmt"
+
+// SuggestionError tells the word likely meant instead of a misspelled word. It is joined to the error about the
+// misspelled word.
+type SuggestionError struct {
+	Word       string
+	Suggestion string
+}
+
+func (e *SuggestionError) Error() string {
+	return fmt.Sprintf("did you mean %s?", e.Suggestion)
+}
+
+// Suggest returns the candidate closest to word, and false if none is close enough for word to be a typo of it.
+// The closest candidate is the one with the fewest edits: insertions, deletions, substitutions and swaps of two
+// adjacent characters. The first candidate wins a tie.
+func Suggest(word string, candidates []string) (string, bool) {
+	limit := max(1, len([]rune(word))/3)
+	best, bestDist := "", limit+1
+	for _, c := range candidates {
+		if d := distance(word, c); d < bestDist && c != word {
+			best, bestDist = c, d
+		}
+	}
+	return best, best != ""
+}
+
+// distance returns the optimal string alignment distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// d[i][j] is the distance between the first i runes of a and the first j runes of b
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,198 @@
+// Package format prints PlankLang source in its canonical form.
+package format
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/parser/types"
+	"strconv"
+	"strings"
+)
+
+var ErrUnprintable = errors.New("unprintable value")
+
+// indent is the indentation of the modifiers written on their own line.
+const indent = "  "
+
+// line is a line of the formatted source.
+type line struct {
+	text         string
+	src          int  // line of the source it comes from
+	continuation bool // modifiers continuing the statement of the previous line
+}
+
+// Source formats src: one statement per line, long keywords, single spaces between the values and no space inside
+// the containers. Comments, modifiers written on their own line and single blank lines are kept.
+// The result of Source is a fixed point: formatting it again does not change it.
+// Only the syntax of src is checked: its variables may be undefined and the files it includes are not read.
+func Source(src []byte) ([]byte, error) {
+	lex, err := lexer.Lex(string(src))
+	if err != nil {
+		return nil, err
+	}
+	tree, err := (&parser.Config{SyntaxOnly: true}).Parse(context.Background(), lex)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []*line
+	for i, f := range tree.Body {
+		if i > 0 {
+			lines = append(lines, &line{text: lexer.FigureDelimiter, src: f.Pos.Line})
+		}
+		for _, s := range f.Stmts {
+			ls, err := statement(s)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, ls...)
+		}
+	}
+
+	srcLines := strings.Split(string(src), "\n")
+	lines = addComments(lines, lex, srcLines)
+
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 && !l.continuation && l.src > 0 && lines[i-1].src < l.src-1 && strings.TrimSpace(srcLines[l.src-1]) == "" {
+			b.WriteString("\n")
+		}
+		b.WriteString(l.text)
+		b.WriteString("\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// addComments inserts the comments of lex in lines. A comment following code stays at the end of the line containing
+// this code, the other ones are written on their own line.
+func addComments(lines []*line, lex *lexer.TokenList, srcLines []string) []*line {
+	for _, c := range lex.Comments() {
+		pos := lex.Position(c.Pos)
+		if strings.TrimSpace(srcLines[pos.Line][:pos.Column]) != "" {
+			trailing := -1
+			for i, l := range lines {
+				if l.src == pos.Line {
+					trailing = i
+				}
+			}
+			if trailing >= 0 {
+				lines[trailing].text += " " + c.Literal
+				continue
+			}
+		}
+
+		i := 0
+		for i < len(lines) && lines[i].src <= pos.Line {
+			i++
+		}
+		l := &line{text: c.Literal, src: pos.Line}
+		if i < len(lines) && lines[i].continuation { // keeps the comment aligned with the modifiers around it
+			l.text = indent + l.text
+			l.continuation = true
+		}
+		lines = append(lines[:i], append([]*line{l}, lines[i:]...)...)
+	}
+	return lines
+}
+
+// statement returns the lines of s, the modifiers written on another line of the source staying on their own line.
+func statement(s *parser.Statement) ([]*line, error) {
+	keyword := s.Keyword
+	if keyword == parser.KeywordOw {
+		keyword = parser.KeywordOverwrite
+	}
+	cur := &line{text: keyword, src: s.Pos.Line}
+	if s.Arguments != nil {
+		args, err := arguments(s.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		if args != "" {
+			cur.text += " " + args
+		}
+	}
+
+	lines := []*line{cur}
+	for _, m := range s.Modifiers {
+		mod := "| " + m.Name
+		if m.Arguments != nil {
+			args, err := arguments(m.Arguments)
+			if err != nil {
+				return nil, err
+			}
+			if args != "" {
+				mod += " " + args
+			}
+		}
+		if m.Pos.Line > cur.src {
+			cur = &line{text: indent + mod, src: m.Pos.Line, continuation: true}
+			lines = append(lines, cur)
+		} else {
+			cur.text += " " + mod
+		}
+	}
+	return lines, nil
+}
+
+// arguments returns the values of args separated by spaces. Arguments made of a single tuple are written between
+// parenthesis, because the parser removes the optional parenthesis around the arguments.
+func arguments(args *types.Tuple) (string, error) {
+	values := args.GetValues()
+	if len(values) == 1 {
+		if _, ok := values[0].(*types.Tuple); ok {
+			return value(args)
+		}
+	}
+	return join(values)
+}
+
+func join(values []types.Value) (string, error) {
+	s := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		s[i], err = value(v)
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(s, " "), nil
+}
+
+func value(v types.Value) (string, error) {
+	switch v := v.(type) {
+	case *parser.Variable:
+		return "$" + v.Name, nil
+	case *types.Tuple:
+		s, err := join(v.GetValues())
+		return "(" + s + ")", err
+	case *types.List:
+		s, err := join(v.GetValues())
+		return "[" + s + "]", err
+	case types.String:
+		return quote(string(v))
+	case types.Int:
+		return strconv.Itoa(int(v)), nil
+	case types.Float:
+		s := strconv.FormatFloat(float64(v), 'f', -1, 64)
+		if !strings.Contains(s, ".") { // 2.0 must stay a float
+			s += ".0"
+		}
+		return s, nil
+	case types.Literal:
+		return v.Value().(string), nil
+	}
+	return "", errors.Join(ErrUnprintable, fmt.Errorf("cannot print %v", v.Value()))
+}
+
+// quote returns s between double quotes, or between the first other quote it does not contain.
+func quote(s string) (string, error) {
+	for _, q := range []string{`"`, `'`, "`"} {
+		if !strings.Contains(s, q) {
+			return q + s + q, nil
+		}
+	}
+	return "", errors.Join(ErrUnprintable, fmt.Errorf("string %s contains every quote", s))
+}
@@ -0,0 +1,59 @@
+package format
+
+import (
+	"errors"
+	"github.com/planklang/goplank/lexer"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"", ""},
+		{"plot   [ 1 2 3 ]  [4 5 6]", "plot [1 2 3] [4 5 6]\n"},
+		{"axis x ;; ow plot | color ( 255 0 0 )", "axis x\noverwrite plot | color 255 0 0\n"},
+		{"plot [.5 2.0] | color ((1 2))", "plot [0.5 2.0] | color ((1 2))\n"},
+		{"plot [1] 'say \"hi\"'", "plot [1] 'say \"hi\"'\n"},
+		{"default plot | width 2", "default plot | width 2\n"},
+		{"plot [1]\n---\n\nplot [2]\n-----", "plot [1]\n---\n\nplot [2]\n---\n"},
+		{"plot [1]\n\n\n\naxis x", "plot [1]\n\naxis x\n"},
+		{"plot [1]\n    | color red\n| width 2 | dash dotted", "plot [1]\n  | color red\n  | width 2 | dash dotted\n"},
+		{"# header\n\nplot [1]   # data  \n", "# header\n\nplot [1] # data\n"},
+		{"plot [1]\n# between\n  | color red # red\n# end", "plot [1]\n  # between\n  | color red # red\n# end\n"},
+		{"axis x ;; plot [1] # second\n---  # figure", "axis x\nplot [1] # second\n--- # figure\n"},
+		{"let c  ( 255 0 0 )\nlet d [ 1 2 ]\nplot $d  [ 3 4 ] | color $c", "let c (255 0 0)\nlet d [1 2]\nplot $d [3 4] | color $c\n"},
+	}
+
+	for _, test := range tests {
+		res, err := Source([]byte(test.src))
+		if err != nil {
+			t.Error("Unexpected error for", test.src, err)
+			continue
+		}
+		if string(res) != test.expected {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.src, res)
+		}
+		again, err := Source(res)
+		if err != nil || string(again) != string(res) {
+			t.Errorf("Expected %q to be formatted, got %q %v", res, again, err)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	if _, err := Source([]byte("plot [1 2")); !errors.Is(err, lexer.ErrInvalidExpression) {
+		t.Error("Expected", lexer.ErrInvalidExpression, "got", err)
+	}
+}
+
+func TestSourceSyntaxOnly(t *testing.T) {
+	res, err := Source([]byte("include  \"/missing.plank\"\nplot [$xs 2]  | color $c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "include \"/missing.plank\"\nplot [$xs 2] | color $c\n"; string(res) != expected {
+		t.Errorf("Expected %q without reading the included file, got %q", expected, res)
+	}
+}
@@ -0,0 +1,281 @@
+// Package goplank compiles PlankLang documents and renders their figures.
+//
+// It is the supported way to use goplank from Go:
+//
+//	doc, err := goplank.Compile(src, nil)
+//	if err != nil {
+//		return err
+//	}
+//	warnings, err := doc.Render(w, "svg", nil)
+//
+// The errors of Compile are the errors of the lexer and of the parser, located with an *errorshelper.Error. For
+// untrusted sources, Limits bounds the resources used by a document, and the functions taking a context stop when it
+// is done. The functions of the package never panic: an unexpected panic is returned as an error matching
+// parser.ErrInternal.
+package goplank
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/parser/types"
+	"github.com/planklang/goplank/render"
+	_ "github.com/planklang/goplank/render/backends"
+	"github.com/planklang/goplank/token"
+	"io"
+	"io/fs"
+	"unicode"
+)
+
+var (
+	// ErrLimitExceeded is matched by the *errorshelper.LimitError reporting a limit exceeded.
+	ErrLimitExceeded   = errorshelper.ErrLimitExceeded
+	ErrInvalidVariable = errors.New("invalid variable")
+	ErrInvalidFigure   = errors.New("invalid figure")
+	// ErrNoFS is matched by the errors of the files read without Options.FS.
+	ErrNoFS = parser.ErrNoFS
+)
+
+// Options configures the compilation of a document. The zero value, or a nil *Options, uses the defaults.
+type Options struct {
+	// FS is the file system of every file read: the files of CompileFile and the files included by the documents.
+	// No file is read when it is nil, so that untrusted sources cannot read any file: vfs.OS reads any file, vfs.Root
+	// the files under a directory, and an embed.FS the files embedded in the program.
+	FS fs.FS
+	// FileSet registers the sources of the document and of the files it includes, to show their errors with
+	// parser.DiagnoseFiles. A new set is used when nil, returned by Document.FileSet.
+	FileSet *token.FileSet
+	// Variables are defined before the first statement, as if by let statements. Their values are int, float64,
+	// string, slices of them, or a types.Value.
+	Variables map[string]any
+	Limits    Limits
+}
+
+// Limits bounds the resources used by a document, for untrusted sources. A zero field means no limit.
+type Limits struct {
+	MaxSourceSize int // in bytes, of the document and of each file it includes
+	MaxTokens     int // of the document and the files it includes together
+	MaxDepth      int // of nested containers, e.g. 2 for [(1 2) (3 4)]
+	MaxPoints     int // of every plot of the document
+	MaxOutputSize int // in bytes, for each call to Render
+}
+
+// RenderOptions configures the rendering of a document. A nil *RenderOptions renders every figure.
+type RenderOptions struct {
+	// Figures are the indexes, starting at 0, of the figures to render, in order. Every figure when empty.
+	Figures []int
+}
+
+// Document is a compiled document.
+type Document struct {
+	fset     *token.FileSet
+	ast      *parser.Ast
+	limits   Limits
+	warnings []*parser.Warning
+}
+
+// Compile lexes, parses and evaluates src.
+func Compile(src string, opts *Options) (*Document, error) {
+	return CompileContext(context.Background(), src, opts)
+}
+
+// CompileContext compiles src like Compile, stopping with the error of ctx when ctx is done. The files it includes are
+// read from opts.FS, relative to its root.
+func CompileContext(ctx context.Context, src string, opts *Options) (*Document, error) {
+	return compile(ctx, "", src, opts)
+}
+
+// compile compiles src, the source of the file name, which is empty when src is not read from a file.
+func compile(ctx context.Context, name, src string, opts *Options) (doc *Document, err error) {
+	defer recoverInternal(&err)
+	if opts == nil {
+		opts = new(Options)
+	}
+	limits := opts.Limits
+	if limits.MaxSourceSize > 0 && len(src) > limits.MaxSourceSize {
+		return nil, &errorshelper.LimitError{Limit: "bytes of source", Max: limits.MaxSourceSize}
+	}
+	conf := &parser.Config{
+		Variables:     make(map[string]types.Value, len(opts.Variables)),
+		MaxDepth:      limits.MaxDepth,
+		MaxSourceSize: limits.MaxSourceSize,
+		MaxTokens:     limits.MaxTokens,
+		FS:            opts.FS,
+		File:          name,
+	}
+	for name, v := range opts.Variables {
+		value, err := toValue(name, v)
+		if err != nil {
+			return nil, err
+		}
+		conf.Variables[name] = value
+	}
+
+	fset := opts.FileSet
+	if fset == nil {
+		fset = token.NewFileSet()
+	}
+	lex, err := (&lexer.Config{MaxTokens: limits.MaxTokens, File: name, FileSet: fset}).Lex(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := conf.Parse(ctx, lex)
+	if err != nil {
+		return nil, err
+	}
+	if err = tree.EvalContext(ctx, limits.MaxPoints); err != nil {
+		return nil, err
+	}
+	return &Document{fset: fset, ast: tree, limits: limits, warnings: parser.Lint(tree, lex)}, nil
+}
+
+// CompileFile reads the file name from opts.FS and compiles it. The files it includes are relative to its directory.
+func CompileFile(name string, opts *Options) (*Document, error) {
+	if opts == nil || opts.FS == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: ErrNoFS}
+	}
+	b, err := fs.ReadFile(opts.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	return compile(context.Background(), name, string(b), opts)
+}
+
+// FileSet returns the set of the sources of the document and of the files it includes.
+func (d *Document) FileSet() *token.FileSet {
+	return d.fset
+}
+
+// Ast returns the evaluated tree of the document.
+func (d *Document) Ast() *parser.Ast {
+	return d.ast
+}
+
+// Figures returns the evaluated figures of the document, in order.
+func (d *Document) Figures() []*parser.Figure {
+	return d.ast.Body
+}
+
+// Warnings returns the warnings of the document, like unused variables, in the order of the source. They are not
+// errors: the document renders as written.
+func (d *Document) Warnings() []*parser.Warning {
+	return d.warnings
+}
+
+// Render writes the figures of the document with the backend format, e.g. "svg". Nothing is written when it
+// fails. The warnings report what the backend cannot draw exactly.
+func (d *Document) Render(w io.Writer, format string, opts *RenderOptions) ([]render.Warning, error) {
+	return d.RenderContext(context.Background(), w, format, opts)
+}
+
+// RenderContext renders the document like Render, stopping with the error of ctx when ctx is done.
+func (d *Document) RenderContext(ctx context.Context, w io.Writer, format string, opts *RenderOptions) (warns []render.Warning, err error) {
+	defer recoverInternal(&err)
+	r, err := render.Lookup(format)
+	if err != nil {
+		return nil, err
+	}
+	tree := d.ast
+	if opts != nil && len(opts.Figures) > 0 {
+		tree = &parser.Ast{Type: d.ast.Type}
+		for _, i := range opts.Figures {
+			if i < 0 || i >= len(d.ast.Body) {
+				return nil, errors.Join(ErrInvalidFigure, fmt.Errorf("no figure %d in a document of %d figures", i, len(d.ast.Body)))
+			}
+			tree.Body = append(tree.Body, d.ast.Body[i])
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	out := &output{ctx: ctx, max: d.limits.MaxOutputSize}
+	warns, err = r.Render(ctx, out, tree)
+	if out.err != nil { // backends may ignore the errors of their writes
+		err = out.err
+	}
+	if err != nil {
+		return warns, err
+	}
+	_, err = w.Write(out.buf.Bytes())
+	return warns, err
+}
+
+// output buffers the output of a backend, failing when ctx is done or when it exceeds max bytes.
+type output struct {
+	ctx context.Context
+	buf bytes.Buffer
+	max int // 0 means no limit
+	err error
+}
+
+func (o *output) Write(b []byte) (int, error) {
+	if o.err == nil {
+		o.err = o.ctx.Err()
+	}
+	if o.err == nil && o.max > 0 && o.buf.Len()+len(b) > o.max {
+		o.err = &errorshelper.LimitError{Limit: "bytes of output", Max: o.max}
+	}
+	if o.err != nil {
+		return 0, o.err
+	}
+	return o.buf.Write(b)
+}
+
+// recoverInternal turns a panic into an error matching parser.ErrInternal, set in *err.
+func recoverInternal(err *error) {
+	if r := recover(); r != nil {
+		*err = errors.Join(parser.ErrInternal, fmt.Errorf("%v", r))
+	}
+}
+
+// toValue converts the value v of the variable name.
+func toValue(name string, v any) (types.Value, error) {
+	if !validName(name) {
+		return nil, errors.Join(ErrInvalidVariable, fmt.Errorf("invalid name %q", name))
+	}
+	switch v := v.(type) {
+	case types.Value:
+		return v, nil
+	case int:
+		return types.Int(v), nil
+	case float64:
+		return types.Float(v), nil
+	case string:
+		return types.String(v), nil
+	case []int:
+		return list(v, func(x int) types.Value { return types.Int(x) })
+	case []float64:
+		return list(v, func(x float64) types.Value { return types.Float(x) })
+	case []string:
+		return list(v, func(x string) types.Value { return types.String(x) })
+	}
+	return nil, errors.Join(ErrInvalidVariable, fmt.Errorf("cannot use %T as the value of %s", v, name))
+}
+
+func list[T any](s []T, fn func(T) types.Value) (types.Value, error) {
+	l := new(types.List)
+	for _, x := range s {
+		if err := l.AddValues(fn(x)); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// validName returns true if name can be used as $name.
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+package goplank
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCompile(t *testing.T) {
+	doc, err := Compile("plot $xs [1 4 9] | label $name\n---\nplot [1 2]", &Options{Variables: map[string]any{
+		"xs":   []int{1, 2, 3},
+		"name": "squares",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if figs := doc.Figures(); len(figs) != 2 || figs[0].Plots[0].X[2] != 3 || figs[0].Plots[0].Label != "squares" {
+		t.Error("Expected the figures with the variables, got", doc.Ast())
+	}
+
+	var buf bytes.Buffer
+	if _, err = doc.Render(&buf, "vegalite", &RenderOptions{Figures: []int{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "squares") {
+		t.Error("Expected only the second figure, got", buf.String())
+	}
+	buf.Reset()
+	if _, err = doc.Render(&buf, "svg", nil); err != nil || !strings.Contains(buf.String(), "squares") {
+		t.Error("Expected every figure, got", err, buf.String())
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	doc, err := Compile("let unused [1 2]\nplot $xs [1 2 3]", &Options{Variables: map[string]any{"xs": []int{1, 2, 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warns := doc.Warnings(); len(warns) != 1 || warns[0].Code != "W0001" || warns[0].Pos.Line != 0 {
+		t.Error("Expected the unused variable only, got", warns)
+	}
+}
+
+func TestCompileFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"figures/a.plank":      {Data: []byte("include \"common.plank\"\nplot [1 2]")},
+		"figures/common.plank": {Data: []byte("default plot | width 3")},
+	}
+	doc, err := CompileFile("figures/a.plank", &Options{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plots := doc.Figures()[0].Plots; len(plots) != 1 || plots[0].Width != 3 {
+		t.Error("Expected a plot with the included default, got", doc.Ast())
+	}
+	if _, err = CompileFile("b.plank", &Options{FS: fsys}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestRootFS(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"secret.plank":         "let secret 1",
+		"project/a.plank":      "include \"style.plank\"\nplot [1 2]",
+		"project/style.plank":  "default plot | width 2",
+		"project/escape.plank": "include \"../secret.plank\"",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsys, err := vfs.Root(filepath.Join(dir, "project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	doc, err := CompileFile("a.plank", &Options{FS: fsys})
+	if err != nil || doc.Figures()[0].Plots[0].Width != 2 {
+		t.Error("Expected the included default, got", err)
+	}
+	if _, err = CompileFile("escape.plank", &Options{FS: fsys}); !errors.Is(err, vfs.ErrPathEscapes) || !errors.Is(err, parser.ErrInvalidInclude) {
+		t.Error("Expected", vfs.ErrPathEscapes, "got", err)
+	}
+	if _, err = Compile("include \"/etc/hostname\"", &Options{FS: fsys}); !errors.Is(err, vfs.ErrPathEscapes) {
+		t.Error("Expected", vfs.ErrPathEscapes, "for an absolute path, got", err)
+	}
+	if _, err = CompileFile("../secret.plank", &Options{FS: fsys}); !errors.Is(err, vfs.ErrPathEscapes) {
+		t.Error("Expected", vfs.ErrPathEscapes, "for the compiled file, got", err)
+	}
+}
+
+func TestNoFS(t *testing.T) {
+	if _, err := Compile("include \"/etc/hostname\"", nil); !errors.Is(err, ErrNoFS) || !errors.Is(err, parser.ErrInvalidInclude) {
+		t.Error("Expected", ErrNoFS, "got", err)
+	}
+	if _, err := CompileFile("a.plank", &Options{}); !errors.Is(err, ErrNoFS) {
+		t.Error("Expected", ErrNoFS, "got", err)
+	}
+}
+
+func TestIncludeLimits(t *testing.T) {
+	fsys := fstest.MapFS{
+		"big.plank":   {Data: []byte("let x [" + strings.Repeat("1 ", 1000) + "]")},
+		"small.plank": {Data: []byte("let x [1 2 3]")},
+	}
+	for _, c := range []struct {
+		src    string
+		limits Limits
+		limit  string
+	}{
+		{"include \"big.plank\"", Limits{MaxSourceSize: 100}, "bytes of source"},
+		{"include \"big.plank\"", Limits{MaxTokens: 50}, "tokens"},
+		{"include \"small.plank\"\ninclude \"small.plank\"", Limits{MaxTokens: 18}, "tokens"},
+	} {
+		_, err := Compile(c.src, &Options{FS: fsys, Limits: c.limits})
+		var limit *errorshelper.LimitError
+		if !errors.As(err, &limit) || limit.Limit != c.limit {
+			t.Error("Expected the", c.limit, "limit for", c.src, "got", err)
+		}
+	}
+	if _, err := Compile("include \"small.plank\"\ninclude \"small.plank\"", &Options{FS: fsys, Limits: Limits{MaxTokens: 19}}); err != nil {
+		t.Error("Expected 19 tokens, got", err)
+	}
+
+	diamond := fstest.MapFS{"0.plank": {Data: []byte("let x 1")}}
+	for i := 1; i <= 30; i++ { // each file includes the previous one twice: 2^30 includes
+		src := fmt.Sprintf("include \"%d.plank\"\ninclude \"%d.plank\"", i-1, i-1)
+		diamond[fmt.Sprintf("%d.plank", i)] = &fstest.MapFile{Data: []byte(src)}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := CompileContext(ctx, "include \"30.plank\"", &Options{FS: diamond})
+	var limit *errorshelper.LimitError
+	if !errors.As(err, &limit) || limit.Limit != "includes" {
+		t.Error("Expected the includes limit, got", err)
+	}
+}
+
+func TestFileSet(t *testing.T) {
+	fsys := fstest.MapFS{"a.plank": {Data: []byte("plot [1 2]")}, "b.plank": {Data: []byte("plot [1 2.5]")}}
+	doc, err := Compile("include \"a.plank\"", &Options{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := doc.FileSet().Lookup("a.plank"); f == nil || f.Source() != "plot [1 2]" {
+		t.Error("Expected the included file in the file set, got", f)
+	}
+
+	fset := token.NewFileSet()
+	_, err = Compile("include \"b.plank\"", &Options{FS: fsys, FileSet: fset})
+	d := parser.DiagnoseFiles(err, fset)
+	if l, ok := d.Primary(); d.Code != "P0009" || !ok || l.File != "b.plank" {
+		t.Error("Expected P0009 in b.plank, got", d)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, c := range []struct {
+		src      string
+		opts     *Options
+		expected error
+	}{
+		{"plot [1 2] | colr red", nil, parser.ErrInvalidModifier},
+		{"plot $xs", nil, parser.ErrUndefinedVariable},
+		{"plot [1 2 3]", &Options{Limits: Limits{MaxSourceSize: 4}}, ErrLimitExceeded},
+		{"plot [1 2 3]", &Options{Limits: Limits{MaxTokens: 4}}, ErrLimitExceeded},
+		{"plot [[[1]]]", &Options{Limits: Limits{MaxDepth: 2}}, ErrLimitExceeded},
+		{"plot [1 2 3]\nplot [4 5]", &Options{Limits: Limits{MaxPoints: 4}}, ErrLimitExceeded},
+		{"plot $x", &Options{Variables: map[string]any{"x-y": 1}}, ErrInvalidVariable},
+		{"plot $x", &Options{Variables: map[string]any{"x": true}}, ErrInvalidVariable},
+	} {
+		if _, err := Compile(c.src, c.opts); !errors.Is(err, c.expected) {
+			t.Error("Expected", c.expected, "for", c.src, "got", err)
+		}
+	}
+
+	_, err := Compile("plot [[1]]", &Options{Limits: Limits{MaxDepth: 1}})
+	var limit *errorshelper.LimitError
+	if !errors.As(err, &limit) || limit.Limit != "nested containers" || limit.Max != 1 {
+		t.Error("Expected the nested containers limit, got", err)
+	}
+
+	doc, err := Compile("plot [1 2]", &Options{Limits: Limits{MaxOutputSize: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err = doc.Render(&buf, "svg", nil); !errors.Is(err, ErrLimitExceeded) || buf.Len() != 0 {
+		t.Error("Expected", ErrLimitExceeded, "got", err, buf.Len())
+	}
+	if _, err = doc.Render(&buf, "svg", &RenderOptions{Figures: []int{1}}); !errors.Is(err, ErrInvalidFigure) {
+		t.Error("Expected", ErrInvalidFigure, "got", err)
+	}
+	if _, err = doc.Render(&buf, "nope", nil); err == nil {
+		t.Error("Expected an unknown backend")
+	}
+}
+
+func TestCompileContext(t *testing.T) {
+	for _, src := range []string{"", "# only a comment\n", "\n\n"} {
+		if _, err := Compile(src, nil); err != nil {
+			t.Error("Expected an empty document for", src, "got", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doc, err := CompileContext(ctx, "plot [1 2]", &Options{Limits: Limits{MaxTokens: 5, MaxDepth: 1, MaxPoints: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err = CompileContext(ctx, "plot [1 2]", nil); !errors.Is(err, context.Canceled) {
+		t.Error("Expected", context.Canceled, "got", err)
+	}
+	var buf bytes.Buffer
+	if _, err = doc.RenderContext(ctx, &buf, "svg", nil); !errors.Is(err, context.Canceled) || buf.Len() != 0 {
+		t.Error("Expected", context.Canceled, "got", err, buf.Len())
+	}
+}
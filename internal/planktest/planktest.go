@@ -0,0 +1,25 @@
+// Package planktest helps the tests of the packages of goplank.
+package planktest
+
+import (
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"testing"
+)
+
+// Eval lexes, parses and evaluates src, and stops the test at the first error.
+func Eval(t testing.TB, src string) *parser.Ast {
+	t.Helper()
+	lex, err := lexer.Lex(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := parser.Parse(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tree.Eval(); err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
@@ -0,0 +1,27 @@
+package lexer
+
+import "fmt"
+
+// SyntaxError is an error of the syntax of a source: Expected was expected at Pos, and Found was found instead.
+// Err is the kind of the error, like ErrUnclosedContainer, matched with errors.Is.
+type SyntaxError struct {
+	Pos      Position
+	Expected string // e.g. ")" or "keyword", empty when anything but Found was expected
+	Found    string // e.g. "end of line" or "identifier(plto)"
+	Err      error
+}
+
+func (e *SyntaxError) Error() string {
+	msg := "unexpected " + e.Found
+	if e.Expected != "" {
+		msg = fmt.Sprintf("expected %s, found %s", e.Expected, e.Found)
+	}
+	if e.Err == nil {
+		return msg
+	}
+	return e.Err.Error() + "\n" + msg
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,29 @@
+package lexer
+
+import (
+	"testing"
+)
+
+// FuzzLex checks that no source makes the lexer panic or return an invalid token.
+func FuzzLex(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"plot [1 2 3] [4 5 6] \"label\" | color red",
+		"axis x 'Time (s)' [0 10] | grid\n---\nplot (1 2)",
+		"let xs [1 2]\nplot $xs ;; ow plot | width 2.5 # comment",
+		"plot ((1 2)) `a b` [.5]",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		lex, err := Lex(src)
+		if err != nil {
+			return
+		}
+		for _, tok := range lex.Tokens() {
+			if tok.Type == "" || lex.FileSet().File(tok.Pos) != lex.File() || tok.End < tok.Pos {
+				t.Error("Invalid token", tok, "at", tok.Pos)
+			}
+		}
+	})
+}
@@ -1,11 +1,14 @@
 package lexer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/token"
 	"slices"
 	"strings"
+	"unicode"
 )
 
 type LexType string
@@ -21,82 +24,146 @@ const (
 	StringType             LexType = "string"
 	IntType                LexType = "int"
 	FloatType              LexType = "float"
+	CommentType            LexType = "comment"
 
 	ImplicitDelimiter = "implicit"
 	FigureDelimiter   = "---"
 )
 
 var (
-	keywords            = []string{"plot", "default", "overwrite", "ow", "axis"}
+	keywords            = []string{"plot", "default", "overwrite", "ow", "axis", "let", "include"}
 	modifierDelimiters  = []string{"|"}
 	statementDelimiters = []string{";;"}
 	weakDelimiters      = []string{"(", ")", "[", "]"}
 
 	ErrInvalidExpression = fmt.Errorf("invalid expression")
+	ErrUnfinishedString  = errors.Join(ErrInvalidExpression, errors.New("unfinished string"))
+	ErrUnclosedContainer = errors.Join(ErrInvalidExpression, errors.New("unclosed container"))
+	ErrUnopenedContainer = errors.Join(ErrInvalidExpression, errors.New("unopened container"))
+	ErrInvalidVariable   = errors.Join(ErrInvalidExpression, errors.New("invalid variable"))
 )
 
+// Position locates a token in its source, see TokenList.Position.
+type Position = token.Position
+
 type Lexer struct {
 	Type    LexType
 	Literal string
+	Pos     token.Pos
+	End     token.Pos // just after the token in the source, equal to Pos for the implicit delimiters
 }
 
 func (lex *Lexer) String() string {
 	return fmt.Sprintf("%s(%s)", lex.Type, lex.Literal)
 }
 
+// Keywords returns the keywords of the language.
+func Keywords() []string {
+	return slices.Clone(keywords)
+}
+
+// Config configures the lexing of a source.
+type Config struct {
+	MaxTokens int            // 0 means no limit
+	File      string         // the name of the source, set in the positions
+	FileSet   *token.FileSet // where the source is registered, a new set when nil
+}
+
 func Lex(content string) (*TokenList, error) {
+	return new(Config).Lex(context.Background(), content)
+}
+
+// Lex splits content into tokens with the configuration c. It stops with the error of ctx when ctx is done.
+func (c *Config) Lex(ctx context.Context, content string) (*TokenList, error) {
+	fset := c.FileSet
+	if fset == nil {
+		fset = token.NewFileSet()
+	}
+	file := fset.AddFile(c.File, content)
 	var lexs []*Lexer
+	var comments []*Lexer // kept aside, so the parser never sees them
 	lines := strings.Split(content, "\n")
 	delimiterAdded := true
 	for ln, line := range lines {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		i := 0
 		words := strings.Fields(line)
+		starts := fieldStarts(line)
+		at := func(col int) token.Pos {
+			return file.Pos(file.LineStart(ln) + col)
+		}
+		position := func(col int) Position {
+			return file.Position(file.LineStart(ln) + col)
+		}
+		pos := func(i int) token.Pos {
+			return at(starts[i])
+		}
+		end := func(i int) token.Pos {
+			return at(starts[i] + len(words[i]))
+		}
 		parenthesisCounter := 0
 		squareBracketsCounter := 0
 		for i < len(words) && words[i][0] != '#' { // skip comments
 			word := words[i]
 			isDelim, typ := isDelimiter(word)
 			if !delimiterAdded && i == 0 && !isDelim { // implicit delimiter
-				lexs = append(lexs, &Lexer{StatementDelimiterType, ImplicitDelimiter})
+				lexs = append(lexs, &Lexer{StatementDelimiterType, ImplicitDelimiter, pos(i), pos(i)})
 			}
 			if isDelim {
 				delimiterAdded = true
 				if typ == FigureDelimiterType {
-					lexs = append(lexs, &Lexer{typ, FigureDelimiter})
+					lexs = append(lexs, &Lexer{typ, FigureDelimiter, pos(i), end(i)})
 				} else {
-					lexs = append(lexs, &Lexer{typ, word})
+					lexs = append(lexs, &Lexer{typ, word, pos(i), end(i)})
 				}
 			} else if slices.Contains(keywords, word) {
-				lexs = append(lexs, &Lexer{KeywordType, word})
+				lexs = append(lexs, &Lexer{KeywordType, word, pos(i), end(i)})
 			} else {
-				ls, err := parseLiteral(&i, words, &parenthesisCounter, &squareBracketsCounter)
-				if err != nil {
-					fmt.Println(genErrorMessage(err, i, words, ln)) // i-1 because every error here leads to i == len(words)
-					return nil, err
+				start := starts[i]
+				ls, err := parseLiteral(&i, words, &parenthesisCounter, &squareBracketsCounter, at(start))
+				if err != nil { // the errors of parseLiteral only know the column inside the word
+					var se *SyntaxError
+					if errors.As(err, &se) {
+						se.Pos = position(start + se.Pos.Column)
+						return nil, locate(err, se.Pos)
+					}
+					return nil, locate(err, position(starts[i]))
 				}
+				for k := 1; k < len(ls); k++ {
+					ls[k-1].End = ls[k].Pos
+				}
+				ls[len(ls)-1].End = end(i) // the last word of the literal, which may be a string of several words
 				lexs = append(lexs, ls...)
 			}
 			i++
 		}
-		if parenthesisCounter != 0 {
-			err := errors.Join(ErrInvalidExpression, fmt.Errorf("missing )"))
-			fmt.Println(genErrorMessage(err, i-1, words, ln))
-			return nil, err
+		if i < len(words) { // the rest of the line is a comment
+			comment := strings.TrimRightFunc(line[starts[i]:], unicode.IsSpace)
+			comments = append(comments, &Lexer{CommentType, comment, pos(i), at(starts[i] + len(comment))})
 		}
-		if squareBracketsCounter != 0 {
-			err := errors.Join(ErrInvalidExpression, fmt.Errorf("missing ]"))
-			fmt.Println(genErrorMessage(err, i-1, words, ln))
-			return nil, err
+		if parenthesisCounter != 0 || squareBracketsCounter != 0 {
+			err := &SyntaxError{Pos: position(starts[i-1] + len(words[i-1])), Expected: ")", Found: "end of line", Err: ErrUnclosedContainer}
+			if parenthesisCounter == 0 {
+				err.Expected = "]"
+			}
+			return nil, locate(err, err.Pos)
+		}
+		if c.MaxTokens > 0 && len(lexs) > c.MaxTokens {
+			return nil, locate(&errorshelper.LimitError{Limit: "tokens", Max: c.MaxTokens}, file.Position(file.Offset(lexs[c.MaxTokens].Pos)))
 		}
 		delimiterAdded = false
 	}
-	for lexs[len(lexs)-1].Type == StatementDelimiterType {
+	for len(lexs) > 0 && lexs[len(lexs)-1].Type == StatementDelimiterType {
 		lexs = lexs[:len(lexs)-1] // remove useless statement delimiter
 	}
-	return &TokenList{list: lexs, index: -1}, nil
+	return &TokenList{list: lexs, index: -1, comments: comments, fset: fset, file: file}, nil
 }
 
-func parseLiteral(i *int, words []string, parenthesisCounter *int, squareBracketsCounter *int) ([]*Lexer, error) {
+// parseLiteral returns the tokens of the literal starting at the word i, at base in the source. The positions of its
+// errors are columns in the word.
+func parseLiteral(i *int, words []string, parenthesisCounter *int, squareBracketsCounter *int, base token.Pos) ([]*Lexer, error) {
 	word := words[*i]
 	f := word[0]
 	if ok, dec := isDigit(word); ok {
@@ -109,106 +176,139 @@ func parseLiteral(i *int, words []string, parenthesisCounter *int, squareBracket
 		} else {
 			typ = IntType
 		}
-		return []*Lexer{{typ, word}}, nil
+		return []*Lexer{{Type: typ, Literal: word, Pos: base}}, nil
 	}
 	switch f {
-	case '$':
-		if len(word) == 1 {
-			return nil, errors.Join(ErrInvalidExpression, fmt.Errorf("$ is reserved to call variables"))
-		}
-		return []*Lexer{{VariableType, word[1:]}}, nil
 	case '"', '\'', '`':
-		s := ""
+		var s strings.Builder
 		finished := false
 		j := 1
 		for *i < len(words) && !finished {
 			c := words[*i]
 			for j < len(c) && !finished {
-				s += string(c[j])
+				s.WriteByte(c[j])
 				if j < len(c)-1 {
 					finished = c[j+1] == f
 				}
 				j++
 			}
-			s += " "
+			s.WriteByte(' ')
 			j = 0
 			*i++
 		}
+		*i-- // the caller moves to the next word
 		if !finished {
-			*i--
-			return nil, errors.Join(ErrInvalidExpression, fmt.Errorf("string is not finished"))
+			return nil, &SyntaxError{Expected: string(f), Found: "end of line", Err: ErrUnfinishedString}
 		}
-		return []*Lexer{{StringType, s[:len(s)-1]}}, nil
+		return []*Lexer{{Type: StringType, Literal: s.String()[:s.Len()-1], Pos: base}}, nil
 	}
 
 	var lexs []*Lexer
 
 	var precType LexType
-	content := ""
+	var content strings.Builder // built by runes, to lex long words in linear time
+	start := 0
 	isDecimal := false
 	acceptContent := true
 
-	fnUpdate := func(newType LexType) {
-		if precType == newType {
+	fnUpdate := func(newType LexType, k int) {
+		if precType == newType && newType != WeakDelimiterType { // each delimiter is a token, e.g. in ((1 2))
 			return
 		}
 		if precType != "" {
 			if precType != WeakDelimiterType {
 				acceptContent = false
 			}
-			lexs = append(lexs, &Lexer{precType, content})
+			lexs = append(lexs, &Lexer{Type: precType, Literal: content.String(), Pos: base + token.Pos(start)})
 		}
-		content = ""
+		content.Reset()
+		start = k
 		precType = newType
 	}
 
-	for _, c := range word {
+	for k, c := range word {
+		if c == '$' && acceptContent {
+			fnUpdate(VariableType, k)
+			if content.Len() > 0 { // $ following a variable, e.g. $a$b
+				return nil, &SyntaxError{Pos: Position{Column: k}, Found: "$", Err: ErrInvalidVariable}
+			}
+			continue // the literal of a variable is its name
+		}
+		if precType == VariableType && (c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			content.WriteRune(c)
+			continue
+		}
 		if slices.Contains(weakDelimiters, string(c)) {
 			switch c {
 			case '(':
 				*parenthesisCounter++
 			case ')':
 				if *parenthesisCounter == 0 {
-					return nil, errors.Join(ErrInvalidExpression, fmt.Errorf("missing ("))
+					return nil, &SyntaxError{Pos: Position{Column: k}, Found: ")", Err: ErrUnopenedContainer}
 				}
 				*parenthesisCounter--
 			case '[':
 				*squareBracketsCounter++
 			case ']':
 				if *squareBracketsCounter == 0 {
-					return nil, errors.Join(ErrInvalidExpression, fmt.Errorf("missing ["))
+					return nil, &SyntaxError{Pos: Position{Column: k}, Found: "]", Err: ErrUnopenedContainer}
 				}
 				*squareBracketsCounter--
 			}
-			fnUpdate(WeakDelimiterType)
+			fnUpdate(WeakDelimiterType, k)
 		} else if !acceptContent {
-			return nil, errors.Join(ErrInvalidExpression, fmt.Errorf("cannot parse %s", word))
+			return nil, &SyntaxError{Pos: Position{Column: k}, Expected: "space", Found: word[k:], Err: ErrInvalidExpression}
 		} else if ok, dec := isDigit(string(c)); ok && (!dec || !isDecimal) {
 			if !isDecimal {
 				isDecimal = dec
 			}
-			if content == "" {
-				content += "0" // turns .5 into 0.5
+			if content.Len() == 0 {
+				content.WriteByte('0') // turns .5 into 0.5
 			}
 			if isDecimal {
 				if precType == IntType {
 					precType = FloatType
 				}
-				fnUpdate(FloatType)
+				fnUpdate(FloatType, k)
 			} else {
-				fnUpdate(IntType)
+				fnUpdate(IntType, k)
 			}
 		} else {
-			fnUpdate(IdentifierType)
+			fnUpdate(IdentifierType, k)
 		}
-		content += string(c)
+		content.WriteRune(c)
 	}
 
-	return append(lexs, &Lexer{precType, content}), nil
+	lexs = append(lexs, &Lexer{Type: precType, Literal: content.String(), Pos: base + token.Pos(start)})
+	for _, l := range lexs {
+		if l.Type == VariableType && l.Literal == "" {
+			col := int(l.Pos - base)
+			found := "end of word"
+			if col+1 < len(word) {
+				found = string([]rune(word[col+1:])[0])
+			}
+			return nil, &SyntaxError{Pos: Position{Column: col}, Expected: "variable name", Found: found, Err: ErrInvalidVariable}
+		}
+	}
+	return lexs, nil
 }
 
-func genErrorMessage(err error, i int, words []string, line int) string {
-	return errorshelper.GenErrorMessage("Parsing error!", err, i, words, line)
+func locate(err error, pos Position) error {
+	return &errorshelper.Error{File: pos.File, Offset: pos.Offset, Line: pos.Line, Column: pos.Column, Err: err}
+}
+
+// fieldStarts returns the column of each word returned by [strings.Fields].
+func fieldStarts(line string) []int {
+	var starts []int
+	inField := false
+	for k, c := range line {
+		space := unicode.IsSpace(c)
+		if !space && !inField {
+			starts = append(starts, k)
+		}
+		inField = !space
+	}
+	return starts
 }
 
 func isDelimiter(word string) (bool, LexType) {
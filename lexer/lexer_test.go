@@ -1,7 +1,10 @@
 package lexer
 
 import (
+	"context"
 	"errors"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/token"
 	"testing"
 )
 
@@ -18,6 +21,18 @@ func TestLex(t *testing.T) {
 	if resList[0].Type != KeywordType || resList[0].Literal != "axis" {
 		t.Error("Expected keyword(axis), got", resList[0])
 	}
+	res, err = Lex("plot [$a $b_2]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resList = res.list
+	if len(resList) != 5 {
+		t.Fatal("Expected 5, got", len(resList), resList)
+	}
+	if resList[2].Type != VariableType || resList[2].Literal != "a" || resList[3].Literal != "b_2" || resList[4].Literal != "]" {
+		t.Error("Expected variable(a) variable(b_2) weak_delimiter(]), got", resList)
+	}
+
 	res, err = Lex("axis x")
 	if err != nil {
 		t.Fatal(err)
@@ -125,6 +140,31 @@ func TestLexLiteral(t *testing.T) {
 		t.Error("Expected string(bonsoir je marche), got", resList[3])
 	}
 
+	res, err = Lex("plot [[1 2]]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resList = res.list
+	if len(resList) != 7 {
+		t.Fatal("Expected 7, got", len(resList), resList)
+	}
+	if resList[2].Literal != "[" || resList[5].Literal != "]" || resList[6].Literal != "]" {
+		t.Error("Expected one token per delimiter, got", resList)
+	}
+
+	res, err = Lex("axis 'a b' [1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resList = res.list
+	if len(resList) != 5 {
+		t.Error("Expected 5, got", len(resList))
+		t.Log(resList)
+	}
+	if resList[2].Type != WeakDelimiterType || resList[2].Literal != "[" {
+		t.Error("Expected delimiter([), got", resList[2])
+	}
+
 	res, err = Lex("axis 1 0.2 .5")
 	if err != nil {
 		t.Fatal(err)
@@ -224,6 +264,13 @@ func TestLexError(t *testing.T) {
 		t.Error("Expected ErrInvalidExpression, got", err)
 	}
 
+	for _, src := range []string{"plot $", "plot [$]", "plot $a$b"} {
+		res, err = Lex(src)
+		if !errors.Is(err, ErrInvalidExpression) {
+			t.Error("Expected ErrInvalidExpression for", src, "got", err, res)
+		}
+	}
+
 	res, err = Lex("axis | color 1.23.3 ")
 	if err == nil {
 		t.Error("Expected error, got", res)
@@ -264,3 +311,110 @@ func TestLexError(t *testing.T) {
 		t.Error("Expected ErrInvalidExpression, got", err)
 	}
 }
+
+func TestLexPosition(t *testing.T) {
+	res, err := Lex("axis x\n  plot [1 'a b' 2]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][3]int{{0, 0, 0}, {0, 5, 5}, {1, 2, 9}, {1, 2, 9}, {1, 7, 14}, {1, 8, 15}, {1, 10, 17}, {1, 16, 23}, {1, 17, 24}}
+	if len(res.list) != len(expected) {
+		t.Fatal("Expected", len(expected), "got", len(res.list), res.list)
+	}
+	for i, l := range res.list {
+		if pos := res.Position(l.Pos); pos != (Position{Line: expected[i][0], Column: expected[i][1], Offset: expected[i][2]}) {
+			t.Errorf("Expected %v for %s, got %v", expected[i], l, pos)
+		}
+	}
+
+	ends := [][3]int{{0, 4, 4}, {0, 6, 6}, {1, 2, 9}, {1, 6, 13}, {1, 8, 15}, {1, 9, 16}, {1, 15, 22}, {1, 17, 24}, {1, 18, 25}}
+	for i, l := range res.list {
+		if end := res.Position(l.End); end != (Position{Line: ends[i][0], Column: ends[i][1], Offset: ends[i][2]}) {
+			t.Errorf("Expected end %v for %s, got %v", ends[i], l, end)
+		}
+	}
+
+	_, err = Lex("axis x\nplot (1 2")
+	var located *errorshelper.Error
+	if !errors.As(err, &located) {
+		t.Fatal("Expected a located error, got", err)
+	}
+	if located.Line != 1 || located.Column != 9 { // where ) is expected
+		t.Error("Expected line 1 column 9, got", located.Line, located.Column)
+	}
+	var se *SyntaxError
+	if !errors.As(err, &se) || se.Pos != (Position{Offset: 16, Line: 1, Column: 9}) || se.Expected != ")" || se.Found != "end of line" || !errors.Is(err, ErrUnclosedContainer) {
+		t.Error("Expected a syntax error expecting ), got", err)
+	}
+}
+
+func TestLexComments(t *testing.T) {
+	res, err := Lex("# only a comment\nplot [1 2]   # trailing  \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := res.Comments()
+	if len(comments) != 2 {
+		t.Fatal("Expected 2, got", len(comments), comments)
+	}
+	if pos := res.Position(comments[0].Pos); comments[0].Literal != "# only a comment" || pos != (Position{Line: 0, Column: 0}) {
+		t.Error("Expected the first comment at 0:0, got", comments[0], pos)
+	}
+	if pos := res.Position(comments[1].Pos); comments[1].Literal != "# trailing" || pos != (Position{Offset: 30, Line: 1, Column: 13}) {
+		t.Error("Expected the trailing comment at 1:13, got", comments[1], pos)
+	}
+	for _, l := range res.list {
+		if l.Type == CommentType {
+			t.Error("Expected no comment in the tokens, got", l)
+		}
+	}
+
+	res, err = Lex("# nothing else\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Next() || res.Last() != nil {
+		t.Error("Expected no token, got", res.list)
+	}
+}
+
+func TestLexConfig(t *testing.T) {
+	c := &Config{MaxTokens: 6}
+	if _, err := c.Lex(context.Background(), "plot [1 2]\naxis x"); err == nil {
+		t.Error("Expected an error with more than 6 tokens")
+	} else {
+		var located *errorshelper.Error
+		if !errors.Is(err, errorshelper.ErrLimitExceeded) || !errors.As(err, &located) || located.Line != 1 || located.Column != 0 {
+			t.Error("Expected the limit at the 7th token, got", err)
+		}
+	}
+	if _, err := c.Lex(context.Background(), "plot [1 2]"); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Lex(ctx, "plot [1 2]"); err != context.Canceled {
+		t.Error("Expected", context.Canceled, "got", err)
+	}
+}
+
+func TestLexFileSet(t *testing.T) {
+	fset := token.NewFileSet()
+	fset.AddFile("a.plank", "let x 1")
+	res, err := (&Config{File: "b.plank", FileSet: fset}).Lex(context.Background(), "axis x\nplot [1 2]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FileSet() != fset || fset.Lookup("b.plank") != res.File() || res.File().Source() != "axis x\nplot [1 2]" {
+		t.Fatal("Expected the source registered in the set, got", res.File())
+	}
+	tok := res.list[4] // [
+	if pos := fset.Position(tok.Pos); pos != res.Position(tok.Pos) || pos.String() != "b.plank:2:6" {
+		t.Error("Expected b.plank:2:6, got", pos)
+	}
+
+	if res, err := Lex("plot [1 2]"); err != nil || res.File() == nil || res.File().Name() != "" {
+		t.Error("Expected the source registered in a new set, got", err)
+	}
+}
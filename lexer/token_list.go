@@ -1,8 +1,28 @@
 package lexer
 
+import "github.com/planklang/goplank/token"
+
 type TokenList struct {
-	index int
-	list  []*Lexer
+	index    int
+	list     []*Lexer
+	comments []*Lexer
+	fset     *token.FileSet
+	file     *token.File
+}
+
+// FileSet returns the set the source of the tokens is registered in.
+func (list *TokenList) FileSet() *token.FileSet {
+	return list.fset
+}
+
+// File returns the source of the tokens in its file set.
+func (list *TokenList) File() *token.File {
+	return list.file
+}
+
+// Position returns the position of p, the position of a token of the list.
+func (list *TokenList) Position(p token.Pos) Position {
+	return list.file.Position(list.file.Offset(p))
 }
 
 func (list *TokenList) Current() *Lexer {
@@ -20,3 +40,21 @@ func (list *TokenList) Next() bool {
 func (list *TokenList) Empty() bool {
 	return list.index >= len(list.list)
 }
+
+// Last returns the last token of the list, or nil if the list has no token.
+func (list *TokenList) Last() *Lexer {
+	if len(list.list) == 0 {
+		return nil
+	}
+	return list.list[len(list.list)-1]
+}
+
+// Comments returns the comments of the source, in order. They are not part of the tokens iterated by Next.
+func (list *TokenList) Comments() []*Lexer {
+	return list.comments
+}
+
+// Tokens returns every token of the list, whatever the current one is.
+func (list *TokenList) Tokens() []*Lexer {
+	return list.list
+}
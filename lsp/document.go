@@ -0,0 +1,475 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/format"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/token"
+	"github.com/planklang/goplank/vfs"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document is the text of a document, analyzed for a request.
+// Requests are answered from the words of the text rather than from its tree, because the text is usually being
+// written and does not parse.
+type document struct {
+	lines []string
+	path  string // of the file of the document, to which the included files are relative, empty when unknown
+}
+
+func newDocument(text string) *document {
+	return &document{lines: strings.Split(text, "\n")}
+}
+
+// offset returns the line and the byte column of p, clamped to the text.
+func (d *document) offset(p Position) (int, int) {
+	if p.Line < 0 {
+		return 0, 0
+	}
+	if p.Line >= len(d.lines) {
+		return len(d.lines) - 1, len(d.lines[len(d.lines)-1])
+	}
+	line := d.lines[p.Line]
+	units := 0
+	for i, r := range line {
+		if units >= p.Character {
+			return p.Line, i
+		}
+		units += utf16.RuneLen(r)
+	}
+	return p.Line, len(line)
+}
+
+// position returns the LSP position of the byte column col of line.
+func (d *document) position(line, col int) Position {
+	if line >= len(d.lines) {
+		return Position{line, 0}
+	}
+	units := 0
+	for i, r := range d.lines[line] {
+		if i >= col {
+			break
+		}
+		units += utf16.RuneLen(r)
+	}
+	return Position{line, units}
+}
+
+func (d *document) rangeOf(line, start, end int) Range {
+	return Range{d.position(line, start), d.position(line, end)}
+}
+
+// word is a word of a line: a string between quotes or a sequence of characters without space.
+type word struct {
+	text       string
+	start, end int // byte columns
+}
+
+// words returns the words of line before its comment.
+func words(line string) []word {
+	var res []word
+	i := 0
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+		if r == '#' {
+			break
+		}
+		start := i
+		if r == '"' || r == '\'' || r == '`' {
+			if end := strings.IndexRune(line[i+1:], r); end >= 0 {
+				i += end + 2
+			} else {
+				i = len(line)
+			}
+		}
+		for i < len(line) {
+			r, size = utf8.DecodeRuneInString(line[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		res = append(res, word{line[start:i], start, i})
+	}
+	return res
+}
+
+// docContext describes the statement around a column.
+type docContext struct {
+	before  []string // the words of the statement before the current word
+	current string   // the current word, up to the column
+}
+
+// keyword returns the keyword of the statement, or an empty string.
+func (c *docContext) keyword() string {
+	if len(c.before) == 0 {
+		return ""
+	}
+	return c.before[0]
+}
+
+// target returns the statement targeted by the modifiers: the keyword, or its argument for default and overwrite.
+func (c *docContext) target() string {
+	switch k := c.keyword(); k {
+	case parser.KeywordDefault, parser.KeywordOverwrite, parser.KeywordOw:
+		if len(c.before) > 1 {
+			return c.before[1]
+		}
+		return ""
+	default:
+		return k
+	}
+}
+
+// modifier returns the name of the modifier being written, or an empty string.
+func (c *docContext) modifier() string {
+	for i := len(c.before) - 1; i >= 0; i-- {
+		if c.before[i] == "|" {
+			if i+1 < len(c.before) {
+				return c.before[i+1]
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// contextAt returns the context of the column col of line.
+func (d *document) contextAt(line, col int) *docContext {
+	first := line // modifiers may continue the statement on the following lines
+	for first > 0 {
+		ws := words(d.lines[first])
+		if len(ws) == 0 || ws[0].text != "|" {
+			break
+		}
+		first--
+	}
+
+	c := new(docContext)
+	for l := first; l <= line; l++ {
+		for _, w := range words(d.lines[l]) {
+			if l == line && w.start >= col {
+				break
+			}
+			if l == line && col <= w.end {
+				c.current = w.text[:col-w.start]
+				break
+			}
+			if endsStatement(w.text) {
+				c.before = nil
+				continue
+			}
+			c.before = append(c.before, w.text)
+		}
+	}
+	return c
+}
+
+// endsStatement returns true if w is a statement or a figure delimiter.
+func endsStatement(w string) bool {
+	return w == ";;" || len(w) >= 3 && strings.Count(w, "-") == len(w)
+}
+
+// wordAt returns the word containing the column col of line.
+func (d *document) wordAt(line, col int) (word, bool) {
+	for _, w := range words(d.lines[line]) {
+		if w.start <= col && col <= w.end {
+			return w, true
+		}
+	}
+	return word{}, false
+}
+
+// check compiles the document and returns the file set of its sources and its error, or its warnings if it has no
+// error. The source of the document is registered under its path.
+func (d *document) check() (*token.FileSet, []*parser.Warning, error) {
+	fset := token.NewFileSet()
+	lex, err := (&lexer.Config{File: d.path, FileSet: fset}).Lex(context.Background(), strings.Join(d.lines, "\n"))
+	if err != nil {
+		return fset, nil, err
+	}
+	tree, err := (&parser.Config{FS: vfs.OS(), File: d.path}).Parse(context.Background(), lex) // files of the user of the editor
+	if err == nil {
+		err = tree.Eval()
+	}
+	if err != nil {
+		return fset, nil, err
+	}
+	return fset, parser.Lint(tree, lex), nil
+}
+
+func (d *document) diagnostics() []Diagnostic {
+	fset, warns, err := d.check()
+	if err == nil {
+		src := fset.Lookup(d.path).Source()
+		diags := []Diagnostic{}
+		for _, w := range warns {
+			diag := Diagnostic{Severity: SeverityWarning, Code: w.Code, Source: "plank", Message: w.Message}
+			if l, ok := w.Diagnostic(src).Primary(); ok {
+				diag.Range = Range{d.position(l.Line, l.Column), d.position(l.EndLine, l.EndColumn)}
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+
+	diag := Diagnostic{Severity: SeverityError, Code: parser.Code(err), Source: "plank", Message: strings.ReplaceAll(err.Error(), "\n", ": ")}
+	var located *errorshelper.Error
+	if errors.As(err, &located) {
+		diag.Message = strings.ReplaceAll(located.Err.Error(), "\n", ": ")
+	}
+	l, ok := parser.DiagnoseFiles(err, fset).Primary()
+	if ok && located != nil && l.File != d.path { // not in the document, reported on the include statement
+		diag.Message = fmt.Sprintf("%s: %s", located.Position(), diag.Message)
+		diag.Range = d.includeRange(l.File)
+	} else if ok {
+		diag.Range = Range{d.position(l.Line, l.Column), d.position(l.EndLine, l.EndColumn)}
+	}
+	return []Diagnostic{diag}
+}
+
+// includeRange returns the range of the include statement of the document including file, or of its first include
+// statement when file is included by another included file.
+func (d *document) includeRange(file string) Range {
+	lex, err := lexer.Lex(strings.Join(d.lines, "\n"))
+	if err != nil {
+		return Range{}
+	}
+	var res *Range
+	toks := lex.Tokens()
+	for i := 1; i < len(toks); i++ {
+		if toks[i-1].Literal != parser.KeywordInclude || toks[i].Type != lexer.StringType {
+			continue
+		}
+		start, end := lex.Position(toks[i-1].Pos), lex.Position(toks[i].End)
+		r := Range{d.position(start.Line, start.Column), d.position(end.Line, end.Column)}
+		name := toks[i].Literal
+		if !path.IsAbs(name) {
+			name = path.Join(path.Dir(d.path), name)
+		}
+		if name == file {
+			return r
+		}
+		if res == nil {
+			res = &r
+		}
+	}
+	if res == nil {
+		return Range{}
+	}
+	return *res
+}
+
+// codeActions returns the quick fixes of the diagnostics overlapping r, for the document at uri.
+func (d *document) codeActions(uri string, r Range) []CodeAction {
+	actions := []CodeAction{}
+	fset, _, err := d.check()
+	if err == nil {
+		return actions
+	}
+	for _, s := range parser.DiagnoseFiles(err, fset).Suggestions {
+		if s.File != d.path { // a fix of an included file
+			continue
+		}
+		fix := Range{d.position(s.Line, s.Column), d.position(s.EndLine, s.EndColumn)}
+		if before(fix.End, r.Start) || before(r.End, fix.Start) {
+			continue
+		}
+		actions = append(actions, CodeAction{
+			Title:       "Change to " + s.Replacement,
+			Kind:        "quickfix",
+			Diagnostics: d.diagnostics(),
+			IsPreferred: true,
+			Edit:        &WorkspaceEdit{map[string][]TextEdit{uri: {{fix, s.Replacement}}}},
+		})
+	}
+	return actions
+}
+
+// before returns true if a is before b.
+func before(a, b Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+}
+
+func (d *document) completion(line, col int) []CompletionItem {
+	c := d.contextAt(line, col)
+	prefix := c.current
+	if i := strings.LastIndexByte(prefix, '$'); i >= 0 { // variables may be in lists
+		prefix = prefix[i:]
+	}
+	items := []CompletionItem{}
+	add := func(label string, kind int, doc parser.Doc, ok bool) {
+		if !strings.HasPrefix(label, prefix) {
+			return
+		}
+		item := CompletionItem{Label: label, Kind: kind}
+		if ok {
+			item.Detail = doc.Signature
+			item.Documentation = &MarkupContent{"markdown", doc.Summary}
+		}
+		items = append(items, item)
+	}
+
+	switch {
+	case strings.HasPrefix(prefix, "$"):
+		for _, name := range d.variables(line, col) {
+			add("$"+name, CompletionVariable, parser.Doc{}, false)
+		}
+	case len(c.before) == 0:
+		for _, k := range lexer.Keywords() {
+			doc, ok := parser.StatementDoc(k)
+			add(k, CompletionKeyword, doc, ok)
+		}
+	case c.before[len(c.before)-1] == "|":
+		target := c.target()
+		for _, name := range parser.ModifierNames(target) {
+			doc, ok := parser.ModifierDoc(target, name)
+			add(name, CompletionProperty, doc, ok)
+		}
+	case len(c.before) == 1 && c.target() == "":
+		for _, k := range []string{parser.KeywordAxis, parser.KeywordPlot} {
+			doc, ok := parser.StatementDoc(k)
+			add(k, CompletionValue, doc, ok)
+		}
+	case len(c.before) == 1 && c.keyword() == parser.KeywordAxis:
+		add("x", CompletionValue, parser.Doc{}, false)
+		add("y", CompletionValue, parser.Doc{}, false)
+	}
+	return items
+}
+
+func (d *document) hover(line, col int) *Hover {
+	if v, ok := d.variableAt(line, col); ok {
+		def := d.definition(line, col)
+		if def == nil {
+			return nil
+		}
+		r := d.rangeOf(line, v.start, v.end)
+		return &Hover{MarkupContent{"markdown", "```plank\n" + strings.TrimSpace(d.lines[def.Range.Start.Line]) + "\n```"}, &r}
+	}
+	w, ok := d.wordAt(line, col)
+	if !ok {
+		return nil
+	}
+	c := d.contextAt(line, w.start)
+	var text string
+	switch {
+	case len(c.before) == 0 || (len(c.before) == 1 && c.target() == ""):
+		doc, ok := parser.StatementDoc(w.text)
+		if !ok {
+			return nil
+		}
+		text = "```plank\n" + doc.Signature + "\n```\n\n" + doc.Summary
+	case c.before[len(c.before)-1] == "|":
+		doc, ok := parser.ModifierDoc(c.target(), w.text)
+		if !ok {
+			return nil
+		}
+		text = "```plank\n" + doc.Signature + "\n```\n\n" + doc.Summary
+	default:
+		return nil
+	}
+	r := d.rangeOf(line, w.start, w.end)
+	return &Hover{MarkupContent{"markdown", text}, &r}
+}
+
+func (d *document) signatureHelp(line, col int) *SignatureHelp {
+	c := d.contextAt(line, col)
+	var doc parser.Doc
+	var ok bool
+	if name := c.modifier(); name != "" {
+		doc, ok = parser.ModifierDoc(c.target(), name)
+	} else if k := c.keyword(); k != "" {
+		doc, ok = parser.StatementDoc(k)
+	}
+	if !ok {
+		return nil
+	}
+	return &SignatureHelp{Signatures: []SignatureInformation{{doc.Signature, &MarkupContent{"markdown", doc.Summary}}}}
+}
+
+// lets calls fn with the name of each variable defined before the column col of line, and the position of the name.
+func (d *document) lets(line, col int, fn func(name string, line int, w word)) {
+	for l := 0; l <= line && l < len(d.lines); l++ {
+		ws := words(d.lines[l])
+		for i := 0; i+1 < len(ws); i++ {
+			if l == line && ws[i].start >= col {
+				return
+			}
+			if (i == 0 || endsStatement(ws[i-1].text)) && ws[i].text == parser.KeywordLet {
+				fn(ws[i+1].text, l, ws[i+1])
+			}
+		}
+	}
+}
+
+// variables returns the names of the variables defined before the column col of line.
+func (d *document) variables(line, col int) []string {
+	var names []string
+	d.lets(line, col, func(name string, _ int, _ word) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	})
+	slices.Sort(names)
+	return names
+}
+
+// variableAt returns the variable used at the column col of line, in a word like $x or [$x $y].
+func (d *document) variableAt(line, col int) (word, bool) {
+	w, ok := d.wordAt(line, col)
+	if !ok {
+		return word{}, false
+	}
+	start := strings.LastIndexByte(w.text[:min(col-w.start+1, len(w.text))], '$')
+	if start < 0 {
+		return word{}, false
+	}
+	name := w.text[start+1:]
+	if i := strings.IndexFunc(name, func(r rune) bool { return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) }); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" || w.start+start+1+len(name) < col {
+		return word{}, false
+	}
+	return word{name, w.start + start, w.start + start + 1 + len(name)}, true
+}
+
+// definition returns the location of the let statement defining the variable at the column col of line, without
+// URI, or nil.
+func (d *document) definition(line, col int) *Location {
+	v, ok := d.variableAt(line, col)
+	if !ok {
+		return nil
+	}
+	var loc *Location
+	d.lets(line, v.start, func(name string, l int, def word) {
+		if name == v.text {
+			loc = &Location{Range: d.rangeOf(l, def.start, def.end)}
+		}
+	})
+	return loc
+}
+
+func (d *document) formatting() []TextEdit {
+	src := strings.Join(d.lines, "\n")
+	res, err := format.Source([]byte(src))
+	if err != nil || string(res) == src {
+		return []TextEdit{}
+	}
+	last := len(d.lines) - 1
+	return []TextEdit{{Range{Position{0, 0}, d.position(last, len(d.lines[last]))}, string(res)}}
+}
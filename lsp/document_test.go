@@ -0,0 +1,121 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func labels(items []CompletionItem) []string {
+	var res []string
+	for _, i := range items {
+		res = append(res, i.Label)
+	}
+	return res
+}
+
+func TestCompletion(t *testing.T) {
+	for _, c := range []struct {
+		src       string
+		line, col int
+		expected  []string
+	}{
+		{"pl", 0, 2, []string{"plot"}},
+		{"axis x\n", 1, 0, []string{"plot", "default", "overwrite", "ow", "axis", "let", "include"}},
+		{"default ", 0, 8, []string{"axis", "plot"}},
+		{"axis ", 0, 5, []string{"x", "y"}},
+		{"axis x | la", 0, 11, []string{"label"}},
+		{"ow plot\n  | w", 1, 5, []string{"width"}},
+		{"plot [1 2] ;; axis y | s", 0, 24, []string{"scale"}},
+		{"let xs [1]\nlet ys [2]\nplot [$x", 2, 8, []string{"$xs"}},
+		{"plot [1] # | la", 0, 15, []string{}},
+	} {
+		items := newDocument(c.src).completion(c.line, c.col)
+		if got := labels(items); !slices.Equal(got, c.expected) && len(got)+len(c.expected) > 0 {
+			t.Error("Expected", c.expected, "for", c.src, "got", got)
+		}
+	}
+}
+
+func TestSignatureHelp(t *testing.T) {
+	d := newDocument("default axis | range ")
+	if h := d.signatureHelp(0, 21); h == nil || h.Signatures[0].Label != "range [min max]" {
+		t.Error("Expected the signature of range, got", h)
+	}
+	if h := d.signatureHelp(0, 8); h == nil || h.Signatures[0].Label != "default plot|axis | modifiers" {
+		t.Error("Expected the signature of default, got", h)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	d := newDocument("plot [1] \"é😀\" | colr red")
+	line, col := d.offset(Position{0, 17})
+	if line != 0 || col != 20 {
+		t.Error("Expected the byte column 20, got", line, col)
+	}
+	for _, p := range []Position{{-1, 3}, {0, -2}} {
+		if line, col := d.offset(p); line != 0 || col != 0 {
+			t.Error("Expected the start of the text for", p, "got", line, col)
+		}
+	}
+	if p := d.position(0, 20); p.Character != 17 {
+		t.Error("Expected the character 17, got", p)
+	}
+	if diags := d.diagnostics(); len(diags) != 1 || diags[0].Range.Start.Character != 17 {
+		t.Error("Expected a diagnostic at the character 17, got", diags)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	d := newDocument("let unused [1 2]\nplot \"é\" [1 2] | width 1 | width 2")
+	diags := d.diagnostics()
+	if len(diags) != 2 || diags[0].Severity != SeverityWarning || diags[0].Code != "W0001" || diags[1].Code != "W0004" {
+		t.Fatal("Expected two warnings, got", diags)
+	}
+	if r := diags[1].Range; r != (Range{Position{1, 27}, Position{1, 32}}) {
+		t.Error("Expected the range of the second width, got", r)
+	}
+}
+
+func TestCodeActions(t *testing.T) {
+	d := newDocument("let data [1 2]\nplot \"é\" $dta")
+	actions := d.codeActions("file:///a.plank", Range{Position{1, 9}, Position{1, 9}})
+	if len(actions) != 1 || actions[0].Edit == nil {
+		t.Fatal("Expected a quick fix, got", actions)
+	}
+	edits := actions[0].Edit.Changes["file:///a.plank"]
+	if len(edits) != 1 || edits[0].NewText != "$data" || edits[0].Range != (Range{Position{1, 9}, Position{1, 13}}) {
+		t.Error("Expected to replace $dta by $data, got", edits)
+	}
+	if actions := d.codeActions("file:///a.plank", Range{Position{0, 0}, Position{0, 3}}); len(actions) != 0 {
+		t.Error("Expected no quick fix on the first line, got", actions)
+	}
+}
+
+func TestIncludeDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "common.plank"), []byte("let c red\ndefault plot | colr $c"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d := newDocument("plot [1 2]\ninclude \"missing.plank\"\ninclude \"common.plank\"")
+	d.path = filepath.Join(dir, "main.plank")
+	diags := d.diagnostics()
+	if len(diags) != 1 || diags[0].Code != "P0014" || diags[0].Range.Start != (Position{1, 0}) {
+		t.Fatal("Expected the missing file, got", diags)
+	}
+
+	d.lines = []string{"plot [1 2]", "include \"common.plank\""}
+	diags = d.diagnostics()
+	common := filepath.Join(dir, "common.plank")
+	if len(diags) != 1 || diags[0].Range != (Range{Position{1, 0}, Position{1, 22}}) || !strings.HasPrefix(diags[0].Message, common+":2:16: ") {
+		t.Error("Expected the error of common.plank on its include statement, got", diags)
+	}
+	if actions := d.codeActions("file:///main.plank", Range{Position{1, 0}, Position{1, 22}}); len(actions) != 0 {
+		t.Error("Expected no fix of common.plank in the document, got", actions)
+	}
+	if p := filePath("file://" + filepath.ToSlash(d.path)); p != d.path {
+		t.Error("Expected", d.path, "got", p)
+	}
+}
@@ -0,0 +1,246 @@
+// Package lsp implements a Language Server Protocol server for PlankLang.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoShutdown      = errors.New("exit without shutdown")
+	ErrMessageTooLarge = errors.New("message too large")
+)
+
+// maxMessageSize is the largest body of a message, in bytes: its buffer is allocated before reading it.
+const maxMessageSize = 64 << 20
+
+// Server is a language server speaking over a stream, usually stdio.
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]string // text by URI
+	shutdown bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, docs: make(map[string]string)}
+}
+
+// Serve handles the messages until the exit notification or the end of the input. A message that is not valid JSON
+// is answered with a parse error, and only the errors reading the stream stop it.
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		var rerr *responseError
+		if errors.As(err, &rerr) {
+			null := json.RawMessage("null") // the id of the message is unknown
+			if err = s.write(&message{ID: &null, Error: rerr}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrNoShutdown
+			}
+			return nil
+		}
+		result, err := s.handle(msg)
+		if msg.ID == nil { // notification
+			continue
+		}
+		if err != nil {
+			var rerr *responseError
+			if !errors.As(err, &rerr) {
+				rerr = &responseError{codeInvalidParams, err.Error()}
+			}
+			err = s.write(&message{ID: msg.ID, Error: rerr})
+		} else {
+			var b []byte
+			if b, err = json.Marshal(result); err == nil {
+				err = s.write(&message{ID: msg.ID, Result: b})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// read reads a message framed by its Content-Length header. The error is a *responseError when the message is
+// framed but is not valid JSON, so that the next message can be read.
+func (s *Server) read() (*message, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if len(header) == 0 && (err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF)) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	if length > maxMessageSize {
+		return nil, errors.Join(ErrMessageTooLarge, fmt.Errorf("Content-Length %d exceeds %d bytes", length, maxMessageSize))
+	}
+	body := make([]byte, length)
+	if _, err = io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	msg := new(message)
+	if err = json.Unmarshal(body, msg); err != nil {
+		return nil, &responseError{codeParseError, err.Error()}
+	}
+	return msg, nil
+}
+
+func (s *Server) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	return err
+}
+
+func (s *Server) notify(method string, params any) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.write(&message{Method: method, Params: b})
+}
+
+func (s *Server) handle(msg *message) (any, error) {
+	if s.shutdown && msg.Method != "exit" {
+		return nil, &responseError{codeInvalidRequest, "the server is shut down"}
+	}
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":           1, // full
+				"completionProvider":         map[string]any{"triggerCharacters": []string{"|", "$"}},
+				"hoverProvider":              true,
+				"signatureHelpProvider":      map[string]any{"triggerCharacters": []string{" ", "|"}},
+				"definitionProvider":         true,
+				"documentFormattingProvider": true,
+				"codeActionProvider":         map[string]any{"codeActionKinds": []string{"quickfix"}},
+			},
+			"serverInfo": map[string]any{"name": "plank"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		return nil, s.publish(p.TextDocument.URI)
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		s.docs[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		return nil, s.publish(p.TextDocument.URI)
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{p.TextDocument.URI, []Diagnostic{}})
+	case "textDocument/completion", "textDocument/hover", "textDocument/signatureHelp", "textDocument/definition":
+		var p TextDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		text, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			return nil, fmt.Errorf("unknown document %s", p.TextDocument.URI)
+		}
+		d := newDocument(text)
+		line, col := d.offset(p.Position)
+		switch msg.Method {
+		case "textDocument/completion":
+			return d.completion(line, col), nil
+		case "textDocument/hover":
+			return d.hover(line, col), nil
+		case "textDocument/signatureHelp":
+			return d.signatureHelp(line, col), nil
+		default:
+			loc := d.definition(line, col)
+			if loc != nil {
+				loc.URI = p.TextDocument.URI
+			}
+			return loc, nil
+		}
+	case "textDocument/codeAction":
+		var p CodeActionParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		text, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			return nil, fmt.Errorf("unknown document %s", p.TextDocument.URI)
+		}
+		d := newDocument(text)
+		d.path = filePath(p.TextDocument.URI)
+		return d.codeActions(p.TextDocument.URI, p.Range), nil
+	case "textDocument/formatting":
+		var p DocumentFormattingParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		text, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			return nil, fmt.Errorf("unknown document %s", p.TextDocument.URI)
+		}
+		d := newDocument(text)
+		d.path = filePath(p.TextDocument.URI)
+		return d.formatting(), nil
+	}
+	if strings.HasPrefix(msg.Method, "$/") || msg.ID == nil { // optional notifications
+		return nil, nil
+	}
+	return nil, &responseError{codeMethodNotFound, "unsupported method " + msg.Method}
+}
+
+func (s *Server) publish(uri string) error {
+	d := newDocument(s.docs[uri])
+	d.path = filePath(uri)
+	diags := d.diagnostics()
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{uri, diags})
+}
+
+// filePath returns the slash-separated path of the file at uri, or an empty path when uri is not a file URI.
+func filePath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return u.Path
+}
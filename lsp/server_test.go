@@ -0,0 +1,151 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func frame(t *testing.T, msgs ...map[string]any) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		m["jsonrpc"] = "2.0"
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	}
+	return &buf
+}
+
+func responses(t *testing.T, out *bytes.Buffer) []*message {
+	t.Helper()
+	s := &Server{in: bufio.NewReader(out)}
+	var msgs []*message
+	for {
+		msg, err := s.read()
+		if err != nil {
+			return msgs
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestServer(t *testing.T) {
+	uri := "file:///a.plank"
+	doc := map[string]any{"uri": uri}
+	at := func(line, char int) map[string]any {
+		return map[string]any{"textDocument": doc, "position": map[string]any{"line": line, "character": char}}
+	}
+	in := frame(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{"textDocument": map[string]any{
+			"uri": uri, "languageId": "plank", "version": 1, "text": "plot [1 2] | colr red\n",
+		}}},
+		map[string]any{"method": "textDocument/didChange", "params": map[string]any{"textDocument": doc, "contentChanges": []map[string]any{
+			{"text": "let xs [1 2]\nplot  $xs |  \n"},
+		}}},
+		map[string]any{"id": 2, "method": "textDocument/completion", "params": at(1, 13)},
+		map[string]any{"id": 3, "method": "textDocument/hover", "params": at(1, 7)},
+		map[string]any{"id": 4, "method": "textDocument/definition", "params": at(1, 7)},
+		map[string]any{"method": "textDocument/didChange", "params": map[string]any{"textDocument": doc, "contentChanges": []map[string]any{
+			{"text": "let xs [1 2]\nplot  $xs\n"},
+		}}},
+		map[string]any{"id": 5, "method": "textDocument/formatting", "params": map[string]any{"textDocument": doc}},
+		map[string]any{"id": 6, "method": "textDocument/foo", "params": map[string]any{}},
+		map[string]any{"id": 7, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out bytes.Buffer
+	if err := NewServer(in, &out).Serve(); err != nil {
+		t.Fatal(err)
+	}
+	msgs := responses(t, &out)
+	if len(msgs) != 10 {
+		t.Fatal("Expected 10 messages, got", len(msgs))
+	}
+
+	var diags PublishDiagnosticsParams
+	json.Unmarshal(msgs[1].Params, &diags)
+	if msgs[1].Method != "textDocument/publishDiagnostics" || len(diags.Diagnostics) != 1 {
+		t.Fatal("Expected a diagnostic, got", msgs[1].Method, string(msgs[1].Params))
+	}
+	if d := diags.Diagnostics[0]; d.Range != (Range{Position{0, 13}, Position{0, 17}}) || d.Code != "E0002" || !strings.Contains(d.Message, "colr") {
+		t.Error("Expected the diagnostic of colr, got", d)
+	}
+	json.Unmarshal(msgs[2].Params, &diags)
+	if len(diags.Diagnostics) != 1 || diags.Diagnostics[0].Range.Start != (Position{1, 11}) {
+		t.Error("Expected the diagnostic of the missing modifier, got", diags.Diagnostics)
+	}
+
+	var items []CompletionItem
+	json.Unmarshal(msgs[3].Result, &items)
+	if len(items) == 0 || items[0].Label != "color" {
+		t.Error("Expected the plot modifiers, got", items)
+	}
+	var hover Hover
+	json.Unmarshal(msgs[4].Result, &hover)
+	if !strings.Contains(hover.Contents.Value, "let xs [1 2]") {
+		t.Error("Expected the definition of xs, got", hover.Contents.Value)
+	}
+	var loc Location
+	json.Unmarshal(msgs[5].Result, &loc)
+	if loc.URI != uri || loc.Range != (Range{Position{0, 4}, Position{0, 6}}) {
+		t.Error("Expected the location of xs, got", loc)
+	}
+	var edits []TextEdit
+	json.Unmarshal(msgs[6].Params, &diags)
+	if len(diags.Diagnostics) != 0 {
+		t.Error("Expected no diagnostic, got", diags.Diagnostics)
+	}
+	json.Unmarshal(msgs[7].Result, &edits)
+	if len(edits) != 1 || edits[0].NewText != "let xs [1 2]\nplot $xs\n" {
+		t.Error("Expected the formatted document, got", edits)
+	}
+	if msgs[8].Error == nil || msgs[8].Error.Code != codeMethodNotFound {
+		t.Error("Expected an unsupported method, got", msgs[8].Error)
+	}
+}
+
+func TestServerNoShutdown(t *testing.T) {
+	in := frame(t, map[string]any{"method": "exit"})
+	if err := NewServer(in, new(bytes.Buffer)).Serve(); err != ErrNoShutdown {
+		t.Error("Expected", ErrNoShutdown, "got", err)
+	}
+}
+
+func TestServerMessageTooLarge(t *testing.T) {
+	in := bytes.NewBufferString(fmt.Sprintf("Content-Length: %d\r\n\r\n{}", maxMessageSize+1))
+	if err := NewServer(in, new(bytes.Buffer)).Serve(); !errors.Is(err, ErrMessageTooLarge) {
+		t.Error("Expected", ErrMessageTooLarge, "got", err)
+	}
+}
+
+func TestServerParseError(t *testing.T) {
+	in := bytes.NewBufferString("Content-Length: 9\r\n\r\n{invalid}")
+	in.Write(frame(t, map[string]any{"id": 1, "method": "shutdown"}, map[string]any{"method": "exit"}).Bytes())
+	var out bytes.Buffer
+	if err := NewServer(in, &out).Serve(); err != nil {
+		t.Fatal("Expected the server to go on after the invalid message, got", err)
+	}
+	if !strings.Contains(out.String(), `"id":null`) {
+		t.Error("Expected a null id, got", out.String())
+	}
+	msgs := responses(t, &out)
+	if len(msgs) != 2 {
+		t.Fatal("Expected 2 responses, got", len(msgs))
+	}
+	if msgs[0].Error == nil || msgs[0].Error.Code != codeParseError {
+		t.Error("Expected a parse error, got", msgs[0].Error)
+	}
+	if msgs[1].Error != nil || msgs[1].ID == nil || string(*msgs[1].ID) != "1" {
+		t.Error("Expected the shutdown response, got", msgs[1].Error)
+	}
+}
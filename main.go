@@ -1,5 +0,0 @@
-package goplank
-
-func main() {
-
-}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"encoding/json"
 )
 
@@ -16,8 +17,16 @@ type Ast struct {
 }
 
 func (a *Ast) Eval() error {
+	return a.EvalContext(context.Background(), 0)
+}
+
+// EvalContext evaluates the figures like Eval. It stops with the error of ctx when ctx is done, and with a
+// *errorshelper.LimitError when the plots have more than maxPoints points in total, unless maxPoints is 0.
+func (a *Ast) EvalContext(ctx context.Context, maxPoints int) error {
+	sc := newScope() // defaults are shared by the following figures
+	sc.ctx, sc.maxPoints = ctx, maxPoints
 	for _, s := range a.Body {
-		if err := s.Eval(); err != nil {
+		if err := s.eval(sc); err != nil {
 			return err
 		}
 	}
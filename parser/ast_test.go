@@ -108,3 +108,27 @@ func TestParse(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestParseFigures(t *testing.T) {
+	lex, err := lexer.Lex("axis x ;; plot [1] | color red\nplot [2]\n---\naxis y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Parse(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Body) != 2 {
+		t.Fatalf("Excepted 2, got %d", len(tree.Body))
+	}
+	if len(tree.Body[0].Stmts) != 3 {
+		t.Errorf("Excepted 3, got %d", len(tree.Body[0].Stmts))
+	}
+	if len(tree.Body[1].Stmts) != 1 {
+		t.Errorf("Excepted 1, got %d", len(tree.Body[1].Stmts))
+	}
+	plot := tree.Body[0].Stmts[1]
+	if plot.Keyword != "plot" || len(plot.Modifiers) != 1 || plot.Modifiers[0].Name != "color" {
+		t.Error("Expected plot with color, got", plot.Keyword, plot.Modifiers)
+	}
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// Explanation documents an error code, for plank explain.
+type Explanation struct {
+	ErrorCode
+	Text  string // paragraphs separated by blank lines
+	Wrong string // a source with the error, empty when no source gives it
+	Fixed string // the source corrected
+}
+
+var catalog = map[string]struct{ text, wrong, fixed string }{
+	"P0001": {`A word cannot be split into tokens. Numbers, names, variables and brackets may follow each other in a word,
+like [1 or $x], but a name followed by a number cannot go on with letters, like a1b.
+
+Separate the values with spaces, or write the text between quotes.`,
+		`plot [1 2] | label a1b`,
+		`plot [1 2] | label "a1b"`},
+	"P0002": {`A string starts with a quote, which is ", ' or a backquote, and ends with the same quote on the same
+line. The line ends before the closing quote.`,
+		`plot [1 2] | label "sales`,
+		`plot [1 2] | label "sales"`},
+	"P0003": {`A list [ ] or a tuple ( ) is not closed. Containers cannot span several lines: the brackets are counted on
+each line, and every opening bracket must be closed on its line.
+
+The diagnostic points at the end of the line and marks the brackets which are never closed.`,
+		`plot [1 2 3`,
+		`plot [1 2 3]`},
+	"P0004": {`A closing bracket, ) or ], closes no container: there are more closing brackets than opening ones of the
+same kind before it.`,
+		`plot [1 2 3]]`,
+		`plot [1 2 3]`},
+	"P0005": {`$ is reserved to use variables and is followed by the name of a variable, made of letters, digits and _.
+A lone $, or a $ right after the name of a variable like $a$b, is invalid.`,
+		"let data [1 2 3]\nplot $",
+		"let data [1 2 3]\nplot $data"},
+	"P0006": {`A statement starts with a keyword: plot, axis, default, overwrite, its short form ow, let or include. A line
+continuing the previous statement starts with | and a modifier.
+
+When the word looks like a misspelled keyword, the diagnostic suggests it.`,
+		`plto [1 2 3]`,
+		`plot [1 2 3]`},
+	"P0007": {`A statement is followed by something else than a statement delimiter, ;; or a new line, or a figure
+delimiter, ---.
+
+The arguments and the modifiers of a statement take every token up to the next delimiter, so the sources cannot give
+this error today. It guards the parser against a statement ending early.`, "", ""},
+	"P0008": {`The modifier delimiter | is followed by the name of a modifier and its arguments. The statement ends
+right after |, or | is followed by something else than a name.`,
+		`plot [1 2 3] |`,
+		`plot [1 2 3] | color red`},
+	"P0009": {`The values of a list have the same type: numbers of the same kind, strings, or lists of the same type. 1
+is an int and 2.5 a float, so they cannot be in the same list.
+
+Write the ints as floats, like 1.0, or use a tuple ( ), whose values may have different types.`,
+		`plot [1 2.5]`,
+		`plot [1.0 2.5]`},
+	"P0010": {`A variable is used before being defined. Variables are defined by let statements, or by the Go API,
+and are known by the statements which follow their definition, in every following figure.
+
+When a defined variable has a close name, the diagnostic suggests it.`,
+		`plot $data`,
+		"let data [1 2 3]\nplot $data"},
+	"P0011": {`A token is not expected where it is. The more specific codes P0006, P0007 and P0008 are given when they
+apply, so this code is only given by tokens they do not cover.`, "", ""},
+	"P0012": {`A container is closed before its last value: a modifier delimiter, a statement delimiter or a figure
+delimiter comes before the closing bracket.
+
+The lexer closes the containers on their line before the parser runs, so the sources give P0003 instead today. It
+guards the parser against a container ending early.`, "", ""},
+	"P0013": {`A number is out of range. The ints are 64-bit, between -9223372036854775808 and 9223372036854775807, and
+the floats are 64-bit too, up to about 1.8 followed by 308 digits.
+
+Write the large ints as floats, with a decimal point.`,
+		`plot [99999999999999999999]`,
+		`plot [99999999999999999999.0]`},
+	"E0001": {`An argument of a statement or of a modifier has the wrong type or value: an axis which is not x or y, a
+default which modifies neither axis nor plot, data which are not numbers, x and y of different lengths, a let without
+name or value...
+
+The message tells which argument is wrong and what is expected.`,
+		`axis z`,
+		`axis x`},
+	"E0002": {`The statement has no modifier with this name. plot and axis have their own modifiers, and default and
+overwrite accept those of the statement they modify.
+
+When the name looks like a misspelled modifier, the diagnostic suggests it.`,
+		`plot [1 2 3] | colr red`,
+		`plot [1 2 3] | color red`},
+	"E0003": {`A modifier cannot be applied: its arguments are wrong, like a negative width or an unknown color, or the
+statement accepts no modifier, like let.
+
+The message tells what the modifier expects.`,
+		`plot [1 2 3] | width 0`,
+		`plot [1 2 3] | width 2`},
+	"P0014": {`An include statement cannot be read. include is followed by the name of a file between quotes, relative
+to the directory of the including file, like include "common.plank". The file exists and contains statements only,
+without figure delimiter: its statements are inserted in the figure of the include statement.
+
+The examples need several files, see the message for the name of the file.`, "", ""},
+	"P0015": {`A file includes itself, directly or through the files it includes: a.plank includes b.plank which includes
+a.plank. The message lists the files of the cycle.
+
+Move the statements shared by the files of the cycle to another file, included by each of them. The examples need
+several files.`, "", ""},
+	"L0001": {`The document exceeds a limit set to compile untrusted sources: the size of the source, the number of
+tokens, the depth of the nested containers, the number of data points or the size of the output. There is no limit
+by default, they are set by the programs embedding plank, except for the 1000 files a document may include: an
+included file counts again each time it is included.
+
+Split the document, or raise the limit.`, "", ""},
+	"I0001": {`The compiler reached a state which should never happen. The source is not the cause, this is a bug of
+plank: please report it with the source which gives it.`, "", ""},
+	"W0001": {`A variable is defined by let but never used as $name after its definition, before the end of the
+document or before another let defining it again. The definition has no effect.`,
+		"let data [1 2 3]\nlet other [4 5 6]\nplot $data",
+		"let data [1 2 3]\nplot $data"},
+	"W0002": {`A default is overridden before it applies: every modifier it sets is set again by later defaults of the
+same target, before any statement of that target. The later defaults win, so the first one has no effect.`,
+		"default plot | color red\ndefault plot | color blue\nplot [1 2 3]",
+		"default plot | color blue\nplot [1 2 3]"},
+	"W0003": {`An overwrite statement modifies the statements of its target written before it in its figure. With no
+such statement before it, it modifies nothing.
+
+Overwrite does not apply to the statements after it: use default for them.`,
+		"overwrite plot | color red\nplot [1 2 3]",
+		"plot [1 2 3]\noverwrite plot | color red"},
+	"W0004": {`A statement sets the same modifier twice. Modifiers apply from left to right, so only the last one is
+kept.`,
+		"plot [1 2 3] | color red | color blue",
+		"plot [1 2 3] | color blue"},
+	"W0005": {`A figure has no statement: two figure delimiters --- follow each other, or one ends or starts the
+document.`,
+		"plot [1 2 3]\n---\n---\nplot [4 5 6]",
+		"plot [1 2 3]\n---\nplot [4 5 6]"},
+}
+
+// Explain returns the explanation of the error or warning code, e.g. P0003, and false if there is no such code.
+func Explain(code string) (Explanation, bool) {
+	code = strings.ToUpper(code)
+	for _, c := range slices.Concat(codes, lints) {
+		if c.Code == code {
+			e := catalog[code]
+			return Explanation{c, e.text, e.wrong, e.fixed}, true
+		}
+	}
+	return Explanation{}, false
+}
+
+// Explanations returns the explanations of every error and warning code, sorted by code.
+func Explanations() []Explanation {
+	var res []Explanation
+	for _, c := range slices.Concat(codes, lints) {
+		if !slices.ContainsFunc(res, func(e Explanation) bool { return e.Code == c.Code }) {
+			e, _ := Explain(c.Code)
+			res = append(res, e)
+		}
+	}
+	slices.SortFunc(res, func(a, b Explanation) int { return strings.Compare(a.Code, b.Code) })
+	return res
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	for _, c := range slices.Concat(codes, lints) {
+		if e, ok := Explain(c.Code); !ok || e.Text == "" {
+			t.Error("Expected an explanation of", c.Code)
+		}
+	}
+	if len(catalog) != len(Explanations()) {
+		t.Error("Expected an explanation for each code, got", len(catalog), "for", len(Explanations()), "codes")
+	}
+
+	for _, e := range Explanations() {
+		if e.Wrong == "" {
+			continue
+		}
+		if strings.HasPrefix(e.Code, "W") {
+			if got := lintCodes(lint(t, e.Wrong)); !slices.Equal(got, []string{e.Code}) {
+				t.Error("Expected", e.Code, "for the wrong example, got", got)
+			}
+			if got := lint(t, e.Fixed); len(got) != 0 {
+				t.Error("Expected no warning for the fixed example of", e.Code, "got", lintCodes(got))
+			}
+			continue
+		}
+		err := compileError(e.Wrong)
+		if c := Code(err); c != e.Code {
+			t.Error("Expected", e.Code, "for the wrong example, got", c, err)
+		}
+		if err = compileError(e.Fixed); err != nil {
+			t.Error("Expected the fixed example of", e.Code, "to compile, got", err)
+		}
+	}
+
+	if e, ok := Explain("p0003"); !ok || e.Title != "unclosed container" {
+		t.Error("Expected P0003, got", e)
+	}
+	if _, ok := Explain("P9999"); ok {
+		t.Error("Expected no explanation of P9999")
+	}
+}
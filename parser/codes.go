@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"errors"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"github.com/planklang/goplank/token"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrorCode is the stable code of a kind of error. The codes starting with P are syntax errors, E evaluation errors,
+// L limits and I internal errors.
+type ErrorCode struct {
+	Code  string
+	Err   error // the sentinel matched by the errors of this kind
+	Title string
+	Help  string
+}
+
+// codes are the error codes, the most specific first since an error may match several sentinels. Each code is
+// explained in the catalog.
+var codes = []ErrorCode{
+	{"P0002", lexer.ErrUnfinishedString, "unfinished string", "close the string with the quote which opens it, on the same line"},
+	{"P0003", lexer.ErrUnclosedContainer, "unclosed container", "lists and tuples cannot span several lines, close them on the line which opens them"},
+	{"P0004", lexer.ErrUnopenedContainer, "unopened container", "remove the closing bracket or open the container before"},
+	{"P0005", lexer.ErrInvalidVariable, "invalid variable", "$ is followed by the name of a variable defined with let, e.g. $data"},
+	{"P0006", ErrKeywordExpected, "keyword expected", "a statement starts with a keyword: " + strings.Join(lexer.Keywords(), ", ")},
+	{"P0007", ErrDelimiterExcepted, "delimiter expected", "separate the statements with ;; or a new line"},
+	{"P0008", ErrModifierExpected, "modifier expected", "| is followed by the name of a modifier, e.g. | color red"},
+	{"P0009", types.ErrInvalidList, "mixed list", "the values of a list have the same type, use a tuple for values of different types"},
+	{"P0015", ErrIncludeCycle, "include cycle", "remove the include which includes a file again from itself"},
+	{"P0014", ErrInvalidInclude, "invalid include", "include is followed by the name of a file, relative to the including file, e.g. include \"common.plank\""},
+	{"P0010", ErrUndefinedVariable, "undefined variable", "define the variable with let before using it"},
+	{"P0001", lexer.ErrInvalidExpression, "invalid expression", ""},
+	{"P0011", ErrUnexpectedToken, "unexpected token", ""},
+	{"P0012", ErrMissingLiteral, "missing value", ""},
+	{"P0013", ErrInvalidLiteral, "invalid value", "write the ints out of [-9223372036854775808, 9223372036854775807] as floats, with a decimal point"},
+	{"E0002", ErrUnknownModifier, "unknown modifier", ""},
+	{"E0003", ErrInvalidModifier, "invalid modifier", ""},
+	{"E0001", ErrInvalidArgument, "invalid argument", ""},
+	{"L0001", errorshelper.ErrLimitExceeded, "limit exceeded", ""},
+	{"I0001", ErrInternal, "internal error", "this is a bug of plank, please report it"},
+	{"I0001", ErrUnknownValue, "internal error", "this is a bug of plank, please report it"},
+}
+
+// Code returns the code of err, or an empty string if it has none.
+func Code(err error) string {
+	if c, ok := codeOf(err); ok {
+		return c.Code
+	}
+	return ""
+}
+
+func codeOf(err error) (ErrorCode, bool) {
+	for _, c := range codes {
+		if errors.Is(err, c.Err) {
+			return c, true
+		}
+	}
+	return ErrorCode{}, false
+}
+
+// Diagnose returns the diagnostic of err, an error of the source src.
+func Diagnose(err error, src string) *errorshelper.Diagnostic {
+	detail := err.Error()
+	var located *errorshelper.Error
+	if errors.As(err, &located) {
+		detail = located.Err.Error()
+	}
+	detail = detail[strings.LastIndexByte(detail, '\n')+1:] // the message of errors.Join ends with the details
+	d := &errorshelper.Diagnostic{Severity: errorshelper.SeverityError, Message: detail}
+	c, ok := codeOf(err)
+	if ok {
+		d.Code = c.Code
+		d.Message = c.Title
+		if c.Help != "" {
+			d.Help = []string{c.Help}
+		}
+	}
+
+	lines := strings.Split(src, "\n")
+	if located == nil || located.Line >= len(lines) { // no label to give the details
+		if !strings.HasPrefix(detail, d.Message) {
+			d.Message += ": " + detail
+		} else {
+			d.Message = detail
+		}
+		return d
+	}
+	line, ln := lines[located.Line], located.Line
+	primary := errorshelper.Label{Span: span(line, ln, located.Column), Message: detail, Primary: true}
+	b := scanBrackets(line)
+	switch {
+	case errors.Is(err, lexer.ErrUnfinishedString) && b.quoteAt >= 0:
+		primary.Span = errorshelper.Span{Line: ln, Column: b.quoteAt, EndLine: ln, EndColumn: len(strings.TrimRightFunc(line, unicode.IsSpace))}
+	case errors.Is(err, lexer.ErrUnopenedContainer) && b.closeAt >= 0:
+		primary.Span = errorshelper.Span{Line: ln, Column: b.closeAt, EndLine: ln, EndColumn: b.closeAt + 1}
+	case errors.Is(err, lexer.ErrUnclosedContainer) && len(b.opens) > 0:
+		primary.Span = errorshelper.Span{Line: ln, Column: b.end, EndLine: ln, EndColumn: b.end}
+		for _, o := range b.opens {
+			msg := "this " + line[o:o+1] + " is never closed"
+			d.Labels = append(d.Labels, errorshelper.Label{Span: errorshelper.Span{Line: ln, Column: o, EndLine: ln, EndColumn: o + 1}, Message: msg})
+		}
+	}
+	d.Labels = append([]errorshelper.Label{primary}, d.Labels...)
+
+	var sugg *errorshelper.SuggestionError
+	if errors.As(err, &sugg) {
+		d.Help = append([]string{"did you mean `" + sugg.Suggestion + "`?"}, d.Help...)
+		if s := primary.Span; s.Line == s.EndLine && line[s.Column:s.EndColumn] == sugg.Word {
+			d.Suggestions = append(d.Suggestions, errorshelper.Suggestion{Span: s, Replacement: sugg.Suggestion})
+		}
+	}
+	return d
+}
+
+// DiagnoseFiles returns the diagnostic of err, an error of a file of fset: the document or a file it includes. Its
+// spans are in the file of err, named in Span.File.
+func DiagnoseFiles(err error, fset *token.FileSet) *errorshelper.Diagnostic {
+	var name, src string
+	var located *errorshelper.Error
+	if errors.As(err, &located) {
+		name = located.File
+	}
+	if f := fset.Lookup(name); f != nil {
+		src = f.Source()
+	}
+	d := Diagnose(err, src)
+	for i := range d.Labels {
+		d.Labels[i].File = name
+	}
+	for i := range d.Suggestions {
+		d.Suggestions[i].File = name
+	}
+	return d
+}
+
+// span returns the span of the token at the byte column col of line, the line ln of a source.
+func span(line string, ln, col int) errorshelper.Span {
+	end := col
+	switch {
+	case col >= len(line):
+	case line[col] == '"' || line[col] == '\'' || line[col] == '`':
+		end = len(line)
+		if i := strings.IndexByte(line[col+1:], line[col]); i >= 0 {
+			end = col + i + 2
+		}
+	case strings.IndexByte("()[]", line[col]) >= 0:
+		end = col + 1
+	default:
+		end = col + 1
+		for end < len(line) && !unicode.IsSpace(rune(line[end])) && strings.IndexByte("()[]$", line[end]) < 0 {
+			end++
+		}
+	}
+	return errorshelper.Span{Line: ln, Column: col, EndLine: ln, EndColumn: end}
+}
+
+// brackets is what a line leaves open.
+type brackets struct {
+	opens   []int // the columns of the brackets which are never closed
+	closeAt int   // the column of the first closing bracket which closes nothing, or -1
+	quoteAt int   // the column of the quote of an unfinished string, or -1
+	end     int   // the column of the end of the line before its comment and its trailing spaces
+}
+
+func scanBrackets(line string) brackets {
+	var parentheses, squares []int
+	b := brackets{closeAt: -1, quoteAt: -1, end: len(line)}
+	var quote byte
+	for k := 0; k < len(line); k++ {
+		c := line[k]
+		if quote != 0 {
+			if c == quote {
+				quote, b.quoteAt = 0, -1
+			}
+			continue
+		}
+		switch c {
+		case '#':
+			if k == 0 || unicode.IsSpace(rune(line[k-1])) {
+				b.end = k
+				k = len(line)
+			}
+		case '"', '\'', '`':
+			quote, b.quoteAt = c, k
+		case '(':
+			parentheses = append(parentheses, k)
+		case '[':
+			squares = append(squares, k)
+		case ')', ']':
+			stack := &parentheses
+			if c == ']' {
+				stack = &squares
+			}
+			if len(*stack) == 0 {
+				if b.closeAt < 0 {
+					b.closeAt = k
+				}
+				continue
+			}
+			*stack = (*stack)[:len(*stack)-1]
+		}
+	}
+	b.opens = append(parentheses, squares...)
+	slices.Sort(b.opens)
+	b.end = len(strings.TrimRightFunc(line[:b.end], unicode.IsSpace))
+	return b
+}
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"errors"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"strings"
+	"testing"
+)
+
+func compileError(src string) error {
+	lex, err := lexer.Lex(src)
+	if err != nil {
+		return err
+	}
+	tree, err := Parse(lex)
+	if err != nil {
+		return err
+	}
+	return tree.Eval()
+}
+
+func TestCode(t *testing.T) {
+	for src, code := range map[string]string{
+		"plot [1 2":                   "P0003",
+		"plot [1 2]]":                 "P0004",
+		"plot 'a b":                   "P0002",
+		"plot $":                      "P0005",
+		"plto [1 2]":                  "P0006",
+		"plot [1 2] |":                "P0008",
+		"plot [1 a]":                  "P0009",
+		"plot $x":                     "P0010",
+		"plot [1 2] | colr red":       "E0002",
+		"plot [99999999999999999999]": "P0013",
+		"plot [1" + strings.Repeat("0", 400) + ".0]": "P0013",
+	} {
+		err := compileError(src)
+		if err == nil {
+			t.Error("Expected an error for", src)
+			continue
+		}
+		if c := Code(err); c != code {
+			t.Error("Expected", code, "for", src, "got", c, err)
+		}
+	}
+	if c := Code(errors.New("other")); c != "" {
+		t.Error("Expected no code, got", c)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	src := "axis x\nplot [1 (2 3] # comment"
+	d := Diagnose(compileError(src), src)
+	var b strings.Builder
+	if err := d.Render(&b, "a.plank", src, false); err != nil {
+		t.Fatal(err)
+	}
+	expected := `error[P0003]: unclosed container
+ --> a.plank:2:14
+  |
+2 | plot [1 (2 3] # comment
+  |         - this ( is never closed
+  |              ^ expected ), found end of line
+  |
+  = help: lists and tuples cannot span several lines, close them on the line which opens them
+`
+	if b.String() != expected {
+		t.Error("Expected", expected, "got", b.String())
+	}
+
+	src = "plot 'a b"
+	d = Diagnose(compileError(src), src)
+	if l, ok := d.Primary(); !ok || l.Column != 5 || l.EndColumn != 9 {
+		t.Error("Expected the span of the string, got", l)
+	}
+
+	d = Diagnose(&errorshelper.LimitError{Limit: "tokens", Max: 2}, src)
+	if d.Code != "L0001" || d.Message != "limit exceeded: more than 2 tokens" || len(d.Labels) != 0 {
+		t.Error("Expected an unlocated diagnostic, got", d)
+	}
+}
+
+func TestRenderSpans(t *testing.T) {
+	src := "let x [1 2]\n\n\nplot $x\n| color\n  red"
+	d := &errorshelper.Diagnostic{
+		Severity: errorshelper.SeverityWarning,
+		Message:  "example",
+		Labels: []errorshelper.Label{
+			{Span: errorshelper.Span{Line: 4, Column: 2, EndLine: 5, EndColumn: 5}, Message: "several lines", Primary: true},
+			{Span: errorshelper.Span{Line: 0, Column: 4, EndLine: 0, EndColumn: 5}, Message: "defined here"},
+		},
+	}
+	var b strings.Builder
+	d.Render(&b, "", src, true)
+	if !strings.Contains(b.String(), "\x1b[1;33mwarning\x1b[0m") {
+		t.Error("Expected a colored warning, got", b.String())
+	}
+	b.Reset()
+	d.Render(&b, "", src, false)
+	expected := `warning: example
+ --> 5:3
+  |
+1 | let x [1 2]
+  |     - defined here
+...
+5 | | color
+  |   ^^^^^^
+6 |   red
+  | ^^^^^ several lines
+`
+	if b.String() != expected {
+		t.Error("Expected", expected, "got", b.String())
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	for src, expected := range map[string]string{
+		"plto [1 2]":                    "plot",
+		"axis x\nplot [1 2] | colr red": "color",
+		"let data [1 2]\nplot $dta":     "$data",
+		"dfault plot | color red":       "default",
+	} {
+		d := Diagnose(compileError(src), src)
+		if len(d.Suggestions) != 1 || d.Suggestions[0].Replacement != expected || d.Help[0] != "did you mean `"+expected+"`?" {
+			t.Error("Expected to suggest", expected, "for", src, "got", d.Suggestions, d.Help)
+		}
+	}
+	for _, src := range []string{"foo [1 2]", "plot [1 2] | zzz red", "let data [1 2]\nplot $x"} {
+		if d := Diagnose(compileError(src), src); len(d.Suggestions) != 0 {
+			t.Error("Expected no suggestion for", src, "got", d.Suggestions)
+		}
+	}
+
+	if s, ok := errorshelper.Suggest("ove rwrite", []string{"overwrite"}); !ok || s != "overwrite" {
+		t.Error("Expected overwrite, got", s)
+	}
+	if s, ok := errorshelper.Suggest("lpot", []string{"let", "plot"}); !ok || s != "plot" {
+		t.Error("Expected plot for a swap, got", s)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	err := compileError("plot [1 2] |")
+	var se *lexer.SyntaxError
+	if !errors.As(err, &se) || se.Expected != "modifier name" || se.Found != "end of source" || se.Pos != (lexer.Position{Offset: 12, Line: 0, Column: 12}) {
+		t.Error("Expected a syntax error at the end of the source, got", err)
+	}
+	if !errors.Is(err, ErrModifierExpected) || !errors.Is(err, ErrUnexpectedToken) || !errors.Is(err, lexer.ErrInvalidExpression) {
+		t.Error("Expected the sentinels of a missing modifier, got", err)
+	}
+
+	err = compileError("axis x\nplto [1 2]")
+	if !errors.As(err, &se) || se.Expected != "keyword" || se.Found != "identifier(plto)" || se.Pos != (lexer.Position{Offset: 7, Line: 1, Column: 0}) {
+		t.Error("Expected a syntax error on plto, got", err)
+	}
+
+	err = compileError("plot [1 2] | color 1 2.5 3")
+	var te *types.TypeError
+	if !errors.As(err, &te) || te.Want != types.IntType || te.Got != types.FloatType || !errors.Is(err, ErrInvalidArgument) {
+		t.Error("Expected a type error on 2.5, got", err)
+	}
+
+	err = compileError("plot [1 [2]]")
+	if !errors.As(err, &te) || te.Want != types.IntType || !errors.Is(err, types.ErrInvalidList) || !errors.Is(err, ErrInvalidLiteral) {
+		t.Error("Expected a type error on [2], got", err)
+	}
+
+	err = compileError("plot [[] [1] [\"s\"]]")
+	if !errors.As(err, &te) || te.Want.String() != "[int]" || !errors.Is(err, types.ErrInvalidList) {
+		t.Error("Expected a type error on [\"s\"], got", err)
+	}
+}
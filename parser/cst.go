@@ -0,0 +1,259 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/lexer"
+	"strings"
+)
+
+type TriviaKind string
+
+const (
+	TriviaSpace   TriviaKind = "space"
+	TriviaNewline TriviaKind = "newline"
+	TriviaComment TriviaKind = "comment"
+	TriviaSkipped TriviaKind = "skipped" // text ignored by the lexer, e.g. after the end of a string in the same word
+)
+
+// Trivia is a part of the source which is not a token.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
+
+// Token is a token of a [CST]: the token of the lexer, its text in the source and the trivia before it.
+type Token struct {
+	*lexer.Lexer
+	Leading []*Trivia
+	Text    string // empty for the implicit delimiters, whose newline is a trivia
+}
+
+type NodeKind string
+
+const (
+	NodeDocument  NodeKind = "document"
+	NodeFigure    NodeKind = "figure"
+	NodeStatement NodeKind = "statement"
+	NodeModifier  NodeKind = "modifier"
+	NodeArguments NodeKind = "arguments"
+	NodeList      NodeKind = "list"
+	NodeTuple     NodeKind = "tuple"
+	NodeToken     NodeKind = "token"
+)
+
+// Node is a node of a [CST]. The tokens are the leaves, every delimiter belonging to the node it ends or starts:
+// the figure delimiters start the figures, the statement delimiters are children of their figure and the modifier
+// delimiters start the modifiers.
+type Node struct {
+	Kind     NodeKind
+	Token    *Token // only for NodeToken
+	Children []*Node
+}
+
+// Tokens returns the tokens of n, in order.
+func (n *Node) Tokens() []*Token {
+	if n.Kind == NodeToken {
+		return []*Token{n.Token}
+	}
+	var res []*Token
+	for _, c := range n.Children {
+		res = append(res, c.Tokens()...)
+	}
+	return res
+}
+
+// CST is the concrete syntax tree of a source. Unlike [Ast], it keeps everything: writing its tokens with their
+// trivia reproduces the source byte for byte.
+type CST struct {
+	Root     *Node
+	Trailing []*Trivia // after the last token
+}
+
+func (c *CST) String() string {
+	var b strings.Builder
+	for _, t := range c.Root.Tokens() {
+		writeTrivia(&b, t.Leading)
+		b.WriteString(t.Text)
+	}
+	writeTrivia(&b, c.Trailing)
+	return b.String()
+}
+
+func writeTrivia(b *strings.Builder, trivia []*Trivia) {
+	for _, t := range trivia {
+		b.WriteString(t.Text)
+	}
+}
+
+// ParseCST lexes and parses src to its concrete syntax tree. The errors are the syntax errors of [lexer.Lex] and
+// [Parse]: the variables are not resolved and the included files are not read, see [Config.SyntaxOnly].
+func ParseCST(src string) (*CST, error) {
+	lex, err := lexer.Lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = (&Config{SyntaxOnly: true}).Parse(context.Background(), lex); err != nil { // the tree is built from valid tokens only
+		return nil, err
+	}
+
+	offset := lex.File().Offset
+
+	var tokens []*Token
+	var trivia []*Trivia
+	comments := lex.Comments()
+	last := 0
+	// gap adds the trivia of the source between last and to
+	gap := func(to int) {
+		for len(comments) > 0 && offset(comments[0].Pos) < to {
+			c := comments[0]
+			trivia = append(trivia, splitTrivia(src[last:offset(c.Pos)])...)
+			trivia = append(trivia, &Trivia{TriviaComment, c.Literal})
+			last = offset(c.End)
+			comments = comments[1:]
+		}
+		trivia = append(trivia, splitTrivia(src[last:to])...)
+		last = to
+	}
+	for _, l := range lex.Tokens() {
+		gap(offset(l.Pos))
+		tokens = append(tokens, &Token{Lexer: l, Leading: trivia, Text: src[offset(l.Pos):offset(l.End)]})
+		trivia = nil
+		last = offset(l.End)
+	}
+	gap(len(src))
+
+	b := &cstBuilder{tokens: tokens}
+	root, err := b.document()
+	if err != nil {
+		return nil, err
+	}
+	return &CST{Root: root, Trailing: trivia}, nil
+}
+
+// splitTrivia splits s, the text between two tokens which is not a comment, in trivia.
+func splitTrivia(s string) []*Trivia {
+	var res []*Trivia
+	add := func(kind TriviaKind, text string) {
+		if n := len(res); n > 0 && res[n-1].Kind == kind && kind != TriviaNewline {
+			res[n-1].Text += text
+			return
+		}
+		res = append(res, &Trivia{kind, text})
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\n':
+			add(TriviaNewline, "\n")
+		case s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n':
+			add(TriviaNewline, "\r\n")
+			i++
+		case strings.ContainsRune(" \t\r\v\f", rune(s[i])):
+			add(TriviaSpace, s[i:i+1])
+		default:
+			add(TriviaSkipped, s[i:i+1])
+		}
+	}
+	return res
+}
+
+// cstBuilder groups tokens already validated by [Parse] in nodes.
+type cstBuilder struct {
+	tokens []*Token
+	i      int
+}
+
+func (b *cstBuilder) current() *Token {
+	if b.i >= len(b.tokens) {
+		return nil
+	}
+	return b.tokens[b.i]
+}
+
+func (b *cstBuilder) is(typ lexer.LexType) bool {
+	t := b.current()
+	return t != nil && t.Type == typ
+}
+
+func (b *cstBuilder) leaf() *Node {
+	n := &Node{Kind: NodeToken, Token: b.current()}
+	b.i++
+	return n
+}
+
+func (b *cstBuilder) document() (*Node, error) {
+	doc := &Node{Kind: NodeDocument}
+	for {
+		fig := &Node{Kind: NodeFigure}
+		if b.is(lexer.FigureDelimiterType) {
+			fig.Children = append(fig.Children, b.leaf())
+		}
+		for b.current() != nil && !b.is(lexer.FigureDelimiterType) {
+			if b.is(lexer.StatementDelimiterType) {
+				fig.Children = append(fig.Children, b.leaf())
+				continue
+			}
+			stmt, err := b.statement()
+			if err != nil {
+				return nil, err
+			}
+			fig.Children = append(fig.Children, stmt)
+		}
+		doc.Children = append(doc.Children, fig)
+		if b.current() == nil {
+			return doc, nil
+		}
+	}
+}
+
+func (b *cstBuilder) statement() (*Node, error) {
+	if !b.is(lexer.KeywordType) {
+		return nil, errors.Join(ErrInternal, fmt.Errorf("expected keyword, not %s", b.current()))
+	}
+	stmt := &Node{Kind: NodeStatement, Children: []*Node{b.leaf()}}
+	if args := b.arguments(); args != nil {
+		stmt.Children = append(stmt.Children, args)
+	}
+	for b.is(lexer.ModifierDelimiterType) {
+		mod := &Node{Kind: NodeModifier, Children: []*Node{b.leaf(), b.leaf()}} // delimiter and name
+		if args := b.arguments(); args != nil {
+			mod.Children = append(mod.Children, args)
+		}
+		stmt.Children = append(stmt.Children, mod)
+	}
+	return stmt, nil
+}
+
+// arguments returns the arguments starting at the current token, or nil if there is none.
+func (b *cstBuilder) arguments() *Node {
+	args := &Node{Kind: NodeArguments}
+	for b.current() != nil && !b.is(lexer.StatementDelimiterType) && !b.is(lexer.ModifierDelimiterType) &&
+		!b.is(lexer.FigureDelimiterType) {
+		args.Children = append(args.Children, b.value())
+	}
+	if len(args.Children) == 0 {
+		return nil
+	}
+	return args
+}
+
+func (b *cstBuilder) value() *Node {
+	if !b.is(lexer.WeakDelimiterType) {
+		return b.leaf()
+	}
+	n := &Node{Kind: NodeTuple}
+	end := ")"
+	if b.current().Literal == "[" {
+		n.Kind = NodeList
+		end = "]"
+	}
+	n.Children = append(n.Children, b.leaf())
+	for b.current() != nil && (!b.is(lexer.WeakDelimiterType) || b.current().Literal != end) {
+		n.Children = append(n.Children, b.value())
+	}
+	if b.current() != nil {
+		n.Children = append(n.Children, b.leaf())
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"errors"
+	"github.com/planklang/goplank/lexer"
+	"testing"
+)
+
+func TestParseCST(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"# only a comment",
+		"axis x",
+		"  axis x  \"the  label\" [ 0 10 ] # trailing \n\n",
+		"plot [1 2] ;; ow plot | color ( 255 0 0 )\r\n---\r\n\tplot [.5 1.5]\r\n",
+		"plot [1 2]\n    # between\n  | color red   \n----\n# end\n",
+		"plot [1 2] 'ab'cd",
+	} {
+		cst, err := ParseCST(src)
+		if err != nil {
+			t.Error("Unexpected error for", src, err)
+			continue
+		}
+		if cst.String() != src {
+			t.Errorf("Expected %q, got %q", src, cst.String())
+		}
+	}
+}
+
+func TestParseCSTNodes(t *testing.T) {
+	cst, err := ParseCST("plot [1 2] | color red # red\n---\naxis x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := cst.Root
+	if root.Kind != NodeDocument || len(root.Children) != 2 {
+		t.Fatal("Expected a document of 2 figures, got", root)
+	}
+	stmt := root.Children[0].Children[0]
+	if stmt.Kind != NodeStatement || len(stmt.Children) != 3 {
+		t.Fatal("Expected a statement with arguments and a modifier, got", stmt)
+	}
+	if stmt.Children[1].Kind != NodeArguments || stmt.Children[1].Children[0].Kind != NodeList {
+		t.Error("Expected list arguments, got", stmt.Children[1])
+	}
+	mod := stmt.Children[2]
+	if mod.Kind != NodeModifier || mod.Children[1].Token.Text != "color" {
+		t.Error("Expected the color modifier, got", mod)
+	}
+
+	fig := root.Children[1]
+	if fig.Children[0].Token.Type != lexer.FigureDelimiterType {
+		t.Error("Expected the figure to start with its delimiter, got", fig.Children[0])
+	}
+	leading := fig.Children[0].Token.Leading
+	if len(leading) != 3 || leading[1].Kind != TriviaComment || leading[1].Text != "# red" || leading[2].Kind != TriviaNewline {
+		t.Error("Expected the comment before the figure delimiter, got", leading)
+	}
+
+	if _, err = ParseCST("plot | "); !errors.Is(err, lexer.ErrInvalidExpression) {
+		t.Error("Expected", lexer.ErrInvalidExpression, "got", err)
+	}
+}
+
+func TestParseCSTSyntaxOnly(t *testing.T) {
+	src := "include \"/missing.plank\"\nplot [$xs 1] | color $c"
+	cst, err := ParseCST(src)
+	if err != nil {
+		t.Fatal("Expected the syntax only to be checked, got", err)
+	}
+	if cst.String() != src {
+		t.Errorf("Expected %q, got %q", src, cst.String())
+	}
+}
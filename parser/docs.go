@@ -0,0 +1,54 @@
+package parser
+
+// Doc documents a statement or a modifier, for editors.
+type Doc struct {
+	Signature string
+	Summary   string
+}
+
+var statementDocs = map[string]Doc{
+	KeywordPlot: {`plot [x-values] y-values ["label"]`,
+		"Draws a series in the figure. Without x-values, the x-values are 0, 1, 2..."},
+	KeywordAxis: {`axis x|y ["label"] [[min max]]`,
+		"Sets the label, the range and the modifiers of an axis of the figure."},
+	KeywordDefault: {`default plot|axis | modifiers`,
+		"Applies its modifiers to the following plot or axis statements, in every following figure."},
+	KeywordOverwrite: {`overwrite plot|axis | modifiers`,
+		"Applies its modifiers to the previous plot or axis statements of the figure. ow is its short form."},
+	KeywordLet: {`let name value`,
+		"Defines a variable, used as $name in the following statements. Several values define a tuple."},
+	KeywordInclude: {`include "file"`,
+		"Inserts the statements of another file, relative to the current one: its variables, defaults and statements."},
+}
+
+var modifierDocs = map[string]map[string]Doc{
+	KeywordAxis: {
+		"label": {`label "text"`, "Sets the label of the axis."},
+		"range": {`range [min max]`, "Sets the range of the axis, automatic by default."},
+		"scale": {`scale linear|log`, "Sets the scale of the axis, linear by default."},
+		"grid":  {`grid [on|off]`, "Draws the grid lines of the axis. grid alone turns them on."},
+	},
+	KeywordPlot: {
+		"label":  {`label "text"`, "Sets the label of the series, shown in the legend."},
+		"color":  {`color name|"#rrggbb[aa]"|r g b [alpha]`, "Sets the color of the series, chosen by the backend by default. alpha is between 0 and 1."},
+		"width":  {`width number`, "Sets the width of the line, positive."},
+		"style":  {`style line|scatter|bar`, "Draws the series as a line, points or bars, line by default."},
+		"dash":   {`dash solid|dashed|dotted`, "Sets the dash pattern of the line, solid by default."},
+		"marker": {`marker none|circle|square|triangle|diamond|plus`, "Marks the points of the series, none by default."},
+	},
+}
+
+// StatementDoc returns the documentation of the statement keyword.
+func StatementDoc(keyword string) (Doc, bool) {
+	if keyword == KeywordOw {
+		keyword = KeywordOverwrite
+	}
+	d, ok := statementDocs[keyword]
+	return d, ok
+}
+
+// ModifierDoc returns the documentation of the modifier name of the statement keyword, plot or axis.
+func ModifierDoc(keyword, name string) (Doc, bool) {
+	d, ok := modifierDocs[keyword][name]
+	return d, ok
+}
@@ -0,0 +1,21 @@
+package parser
+
+import (
+	"github.com/planklang/goplank/lexer"
+	"testing"
+)
+
+func TestDocs(t *testing.T) {
+	for _, k := range lexer.Keywords() {
+		if _, ok := StatementDoc(k); !ok {
+			t.Error("Expected the documentation of the statement", k)
+		}
+	}
+	for _, k := range []string{KeywordAxis, KeywordPlot} {
+		for _, name := range ModifierNames(k) {
+			if _, ok := ModifierDoc(k, name); !ok {
+				t.Error("Expected the documentation of the modifier", name, "of", k)
+			}
+		}
+	}
+}
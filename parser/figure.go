@@ -1,7 +1,167 @@
 package parser
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+)
+
 type Figure struct {
 	Stmts []*Statement
+	Pos   lexer.Position // of the figure delimiter starting the figure, zero for the first one
+	// filled by Eval
+	Axes  []*Axis `json:",omitempty"`
+	Plots []*Plot `json:",omitempty"`
+}
+
+// scope holds the state shared by the figures of a document.
+type scope struct {
+	ctx       context.Context
+	defaults  map[string][]*Modifier
+	points    int // of the plots evaluated until now
+	maxPoints int
+}
+
+func newScope() *scope {
+	return &scope{ctx: context.Background(), defaults: make(map[string][]*Modifier)}
+}
+
+func (f *Figure) Eval() error {
+	return f.eval(newScope())
+}
+
+// Axis returns the axis with the given target, or nil if the figure does not define it.
+func (f *Figure) Axis(target string) *Axis {
+	for _, a := range f.Axes {
+		if a.Target == target {
+			return a
+		}
+	}
+	return nil
+}
+
+func (f *Figure) eval(s *scope) error {
+	f.Axes = nil
+	f.Plots = nil
+	for _, stmt := range f.Stmts {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		if err := f.evalStatement(stmt, s); err != nil {
+			return locate(err, stmt.Pos)
+		}
+	}
+	return nil
+}
+
+func (f *Figure) evalStatement(stmt *Statement, s *scope) error {
+	arg := resolveArguments(stmt.Arguments)
+	if arg == nil {
+		arg = new(types.Tuple)
+	}
+	mods := resolveModifiers(stmt.Modifiers)
+	switch stmt.Keyword {
+	case KeywordLet, KeywordInclude: // defined and spliced while parsing
+		return nil
+	case KeywordAxis:
+		target, err := axisTarget(arg)
+		if err != nil {
+			return err
+		}
+		a := f.Axis(target)
+		if a == nil { // defaults apply once, when the axis is created
+			a = newAxis(target)
+			if err = applyModifiers(a, axisModifiers, s.defaults[KeywordAxis]); err != nil {
+				return err
+			}
+			f.Axes = append(f.Axes, a)
+		}
+		if err = a.SetArgument(arg); err != nil {
+			return err
+		}
+		return applyModifiers(a, axisModifiers, mods)
+	case KeywordPlot:
+		p := newPlot()
+		if err := applyModifiers(p, plotModifiers, s.defaults[KeywordPlot]); err != nil {
+			return err
+		}
+		if err := p.SetArgument(arg); err != nil {
+			return err
+		}
+		if err := applyModifiers(p, plotModifiers, mods); err != nil {
+			return err
+		}
+		s.points += len(p.Y)
+		if s.maxPoints > 0 && s.points > s.maxPoints {
+			return &errorshelper.LimitError{Limit: "data points", Max: s.maxPoints}
+		}
+		f.Plots = append(f.Plots, p)
+		return nil
+	case KeywordDefault:
+		target, err := keywordTarget(stmt, arg)
+		if err != nil {
+			return err
+		}
+		// check the modifiers now instead of at each use
+		switch target {
+		case KeywordAxis:
+			err = applyModifiers(newAxis(""), axisModifiers, mods)
+		case KeywordPlot:
+			err = applyModifiers(newPlot(), plotModifiers, mods)
+		}
+		if err != nil {
+			return err
+		}
+		s.defaults[target] = append(s.defaults[target], mods...)
+		return nil
+	case KeywordOverwrite, KeywordOw:
+		target, err := keywordTarget(stmt, arg)
+		if err != nil {
+			return err
+		}
+		switch target {
+		case KeywordAxis:
+			for _, a := range f.Axes {
+				if err = applyModifiers(a, axisModifiers, mods); err != nil {
+					return err
+				}
+			}
+		case KeywordPlot:
+			for _, p := range f.Plots {
+				if err = applyModifiers(p, plotModifiers, mods); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return errors.Join(ErrUnknownValue, fmt.Errorf("unknown statement %s", stmt))
+}
+
+func axisTarget(arg *types.Tuple) (string, error) {
+	values := arg.GetValues()
+	if len(values) == 0 || !types.Unwrap(values[0]).Type().Is(types.DefaultLiteralType) {
+		return "", errors.Join(ErrInvalidArgument, fmt.Errorf("axis requires a target (x or y)"))
+	}
+	target := types.Unwrap(values[0]).Value().(string)
+	if target != "x" && target != "y" {
+		return "", errors.Join(ErrInvalidArgument, fmt.Errorf("unknown axis %s, expected x or y", target))
+	}
+	return target, nil
 }
 
-func (f *Figure) Eval() error { return nil }
+// keywordTarget returns the statement targeted by default and overwrite.
+func keywordTarget(stmt *Statement, arg *types.Tuple) (string, error) {
+	values := arg.GetValues()
+	if len(values) != 1 || !types.Unwrap(values[0]).Type().Is(types.DefaultLiteralType) {
+		return "", errors.Join(ErrInvalidArgument, fmt.Errorf("%s requires the statement to modify (axis or plot)", stmt))
+	}
+	target := types.Unwrap(values[0]).Value().(string)
+	if target != KeywordAxis && target != KeywordPlot {
+		return "", errors.Join(ErrInvalidArgument, fmt.Errorf("%s cannot modify %s, expected axis or plot", stmt, target))
+	}
+	return target, nil
+}
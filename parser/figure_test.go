@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"errors"
+	"github.com/planklang/goplank/lexer"
+	"testing"
+)
+
+func evalString(t *testing.T, content string) (*Ast, error) {
+	lex, err := lexer.Lex(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Parse(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, tree.Eval()
+}
+
+func TestFigure_Eval(t *testing.T) {
+	tree, err := evalString(t, "axis x 'Time' [0 10]\naxis y | scale log | grid\nplot [1 2 3] [1 4 9] 'squares' | color red | width 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fig := tree.Body[0]
+	if len(fig.Axes) != 2 {
+		t.Fatal("Expected 2, got", len(fig.Axes))
+	}
+	x := fig.Axis("x")
+	if x.Label != "Time" {
+		t.Error("Expected Time, got", x.Label)
+	}
+	if !x.HasRange() || x.Range != [2]float64{0, 10} {
+		t.Error("Expected [0 10], got", x.Range)
+	}
+	y := fig.Axis("y")
+	if y.Scale != ScaleLog || !y.Grid {
+		t.Error("Expected log scale with grid, got", y.Scale, y.Grid)
+	}
+	if len(fig.Plots) != 1 {
+		t.Fatal("Expected 1, got", len(fig.Plots))
+	}
+	p := fig.Plots[0]
+	if p.Label != "squares" {
+		t.Error("Expected squares, got", p.Label)
+	}
+	if len(p.X) != 3 || p.X[2] != 3 || p.Y[2] != 9 {
+		t.Error("Expected [1 2 3] [1 4 9], got", p.X, p.Y)
+	}
+	if p.Color == nil || p.Color.R != 255 || p.Color.G != 0 {
+		t.Error("Expected red, got", p.Color)
+	}
+	if p.Width != 2 {
+		t.Error("Expected 2, got", p.Width)
+	}
+
+	tree, err = evalString(t, "plot [4 5]\n| color (0 0 255 0.5)\n| marker circle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = tree.Body[0].Plots[0]
+	if p.X[0] != 0 || p.X[1] != 1 {
+		t.Error("Expected [0 1], got", p.X)
+	}
+	if p.Color == nil || p.Color.B != 255 || p.Color.A != 128 {
+		t.Error("Expected (0 0 255 128), got", p.Color)
+	}
+	if p.Marker != MarkerCircle {
+		t.Error("Expected circle, got", p.Marker)
+	}
+}
+
+func TestFigure_EvalDefaultOverwrite(t *testing.T) {
+	tree, err := evalString(t, "default plot | color blue\nplot [1 2]\nplot [3 4] | color red\now plot | dash dashed\n---\nplot [5 6]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Body) != 2 {
+		t.Fatal("Expected 2, got", len(tree.Body))
+	}
+	plots := tree.Body[0].Plots
+	if plots[0].Color.B != 255 || plots[1].Color.R != 255 {
+		t.Error("Expected blue then red, got", plots[0].Color, plots[1].Color)
+	}
+	for _, p := range plots {
+		if p.Dash != DashDashed {
+			t.Error("Expected dashed, got", p.Dash)
+		}
+	}
+	p := tree.Body[1].Plots[0]
+	if p.Color == nil || p.Color.B != 255 {
+		t.Error("Expected default to apply to the next figure, got", p.Color)
+	}
+	if p.Dash != DashSolid {
+		t.Error("Expected overwrite to stay in its figure, got", p.Dash)
+	}
+}
+
+func TestFigure_EvalError(t *testing.T) {
+	_, err := evalString(t, "plot [1 2] | colr red")
+	if !errors.Is(err, ErrInvalidModifier) {
+		t.Error("Expected ErrInvalidModifier, got", err)
+	}
+	_, err = evalString(t, "plot [1 2] [1]")
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Error("Expected ErrInvalidArgument, got", err)
+	}
+	_, err = evalString(t, "axis z")
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Error("Expected ErrInvalidArgument, got", err)
+	}
+	_, err = evalString(t, "default plot | width 0")
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Error("Expected ErrInvalidArgument, got", err)
+	}
+	_, err = evalString(t, "default x | width 1")
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Error("Expected ErrInvalidArgument, got", err)
+	}
+}
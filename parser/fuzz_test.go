@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"context"
+	"github.com/planklang/goplank/lexer"
+	"testing"
+	"testing/fstest"
+)
+
+// FuzzParse checks that no source makes the parser or the evaluation panic. Run it with
+// go test -fuzz FuzzParse ./parser: the failing inputs are written to testdata/fuzz, to be kept as regression tests.
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"plot [1 2 3] [4 5 6] \"label\" | color red",
+		"axis x 'Time (s)' [0 10] | grid | scale log\n---\nplot (1 2)",
+		"default plot | width 2\nplot [1 2] | marker circle | style scatter\now plot | dash dotted",
+		"let xs [1 2]\nlet c 0 0 255 0.5\nplot $xs | color $c",
+		"plot [[1 2] [3 4]]",
+		"plot []",
+		"plot [(1 2) ([3])]",
+		"plot [[] [1] [\"s\"]]",
+		"include \"a.plank\"\nplot [1 2]",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		lex, err := lexer.Lex(src)
+		if err != nil {
+			return
+		}
+		conf := &Config{FS: fstest.MapFS{}} // the includes never read the files of the OS
+		tree, err := conf.Parse(context.Background(), lex)
+		if err != nil {
+			return
+		}
+		_ = tree.Eval()
+		_ = tree.String()
+	})
+}
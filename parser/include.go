@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidInclude = errors.New("invalid include")
+	ErrIncludeCycle   = errors.Join(ErrInvalidInclude, errors.New("include cycle"))
+	// ErrNoFS is matched by the errors of the files read without file system.
+	ErrNoFS = errors.New("no file system to read the file from")
+)
+
+// maxIncludes bounds the number of files included by a document, counting a file again at each include, so that files
+// including each other several times cannot grow the document exponentially.
+const maxIncludes = 1000
+
+// include parses the file included by stmt, an include statement: include "file". It returns the statements of the
+// file, which are spliced after stmt and share the variables and the defaults of the document.
+func (st *state) include(stmt *Statement) ([]*Statement, error) {
+	if len(stmt.Modifiers) > 0 {
+		return nil, errors.Join(ErrInvalidModifier, fmt.Errorf("%s does not accept modifiers", stmt))
+	}
+	var values []types.Value
+	if stmt.Arguments != nil {
+		values = stmt.Arguments.GetValues()
+	}
+	if len(values) != 1 {
+		return nil, errors.Join(ErrInvalidInclude, fmt.Errorf("%s requires the name of a file", stmt))
+	}
+	v := types.Unwrap(values[0])
+	if !v.Type().Is(types.StringType) {
+		ctx := fmt.Sprintf("the file of %s", stmt)
+		return nil, &types.TypeError{Want: types.StringType, Got: v.Type(), Context: ctx, Err: ErrInvalidInclude}
+	}
+
+	name := includePath(st.files[len(st.files)-1], v.Value().(string))
+	if i := slices.Index(st.files, name); i >= 0 {
+		cycle := strings.Join(append(slices.Clone(st.files[i:]), name), " includes ")
+		return nil, errors.Join(ErrIncludeCycle, fmt.Errorf("%s", cycle))
+	}
+	if st.includes++; st.includes > maxIncludes {
+		return nil, &errorshelper.LimitError{Limit: "includes", Max: maxIncludes}
+	}
+	src, ok := st.sources[name]
+	if !ok {
+		b, err := st.conf.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		src = string(b)
+		if st.sources == nil {
+			st.sources = make(map[string]string)
+		}
+		st.sources[name] = src
+	}
+	lex, err := (&lexer.Config{File: name, FileSet: st.fset}).Lex(st.ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if st.tokens += len(lex.Tokens()); st.conf.MaxTokens > 0 && st.tokens > st.conf.MaxTokens {
+		return nil, &errorshelper.LimitError{Limit: "tokens", Max: st.conf.MaxTokens}
+	}
+
+	st.files = append(st.files, name)
+	defer func() { st.files = st.files[:len(st.files)-1] }()
+	tree, err := st.parse(lex)
+	if err != nil {
+		return nil, err
+	}
+	if len(tree.Body) > 1 {
+		err = errors.Join(ErrInvalidInclude, fmt.Errorf("the figure delimiters of %s would split the figure including it", name))
+		return nil, locate(err, tree.Body[1].Pos)
+	}
+	return tree.Body[0].Stmts, nil
+}
+
+// Includes returns the names of the files included by the include statements of lex, resolved like Parse does from
+// the file of lex. The statements are found from the tokens, so that the files of a source which does not parse are
+// still returned.
+func Includes(lex *lexer.TokenList) []string {
+	var names []string
+	toks := lex.Tokens()
+	for i := 1; i < len(toks); i++ {
+		if toks[i-1].Type == lexer.KeywordType && toks[i-1].Literal == KeywordInclude && toks[i].Type == lexer.StringType {
+			names = append(names, includePath(lex.File().Name(), toks[i].Literal))
+		}
+	}
+	return names
+}
+
+// includePath returns the name of the file included as name by the file from. Absolute names are kept, for the file
+// systems which accept them.
+func includePath(from, name string) string {
+	if path.IsAbs(name) {
+		return name
+	}
+	return path.Join(path.Dir(from), name)
+}
+
+// readFile returns the content of the included file name from c.FS, and an error matching ErrNoFS without file
+// system. It reads at most c.MaxSourceSize bytes, so that files without end like /dev/zero stop too.
+func (c *Config) readFile(name string) ([]byte, error) {
+	fsys := c.FS
+	if fsys == nil {
+		return nil, errors.Join(ErrInvalidInclude, &fs.PathError{Op: "open", Path: name, Err: ErrNoFS})
+	}
+	if c.MaxSourceSize <= 0 {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidInclude, err)
+		}
+		return b, nil
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidInclude, err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, int64(c.MaxSourceSize)+1))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidInclude, err)
+	}
+	if len(b) > c.MaxSourceSize {
+		return nil, &errorshelper.LimitError{Limit: "bytes of source", Max: c.MaxSourceSize}
+	}
+	return b, nil
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"github.com/planklang/goplank/token"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func compileInclude(fsys fstest.MapFS, name string) (*Ast, error) {
+	lex, err := (&lexer.Config{File: name}).Lex(context.Background(), string(fsys[name].Data))
+	if err != nil {
+		return nil, err
+	}
+	tree, err := (&Config{FS: fsys, File: name}).Parse(context.Background(), lex)
+	if err != nil {
+		return nil, err
+	}
+	return tree, tree.Eval()
+}
+
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"figs/main.plank":         {Data: []byte("include \"style/common.plank\"\nplot $data")},
+		"figs/style/common.plank": {Data: []byte("include \"colors.plank\"\nlet data [1 2 3]\ndefault plot | color $c")},
+		"figs/style/colors.plank": {Data: []byte("let c red")},
+	}
+	tree, err := compileInclude(fsys, "figs/main.plank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stmts := tree.Body[0].Stmts; len(stmts) != 6 || stmts[1].Keyword != KeywordInclude || stmts[5].Pos.File != "figs/main.plank" {
+		t.Fatal("Expected the included statements after their include, got", stmts)
+	}
+	p := tree.Body[0].Plots[0]
+	if len(p.Y) != 3 || p.Color == nil || p.Color.R != 255 {
+		t.Error("Expected the variables and the defaults of the included files, got", p)
+	}
+	if pos := tree.Body[0].Stmts[2].Pos; pos.File != "figs/style/colors.plank" || pos.Line != 0 {
+		t.Error("Expected the position in colors.plank, got", pos)
+	}
+}
+
+func TestIncludeError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		fsys   fstest.MapFS
+		err    error
+		file   string
+		line   int
+		column int
+	}{
+		"syntax": {fstest.MapFS{
+			"main.plank": {Data: []byte("plot [1 2]\ninclude \"a.plank\"")},
+			"a.plank":    {Data: []byte("let x 1\nplot [1 2")},
+		}, lexer.ErrUnclosedContainer, "a.plank", 1, 9},
+		"evaluation": {fstest.MapFS{
+			"main.plank": {Data: []byte("include \"a.plank\"\nplot [1 2]")},
+			"a.plank":    {Data: []byte("default plot | colr red")},
+		}, ErrUnknownModifier, "a.plank", 0, 15},
+		"missing": {fstest.MapFS{
+			"main.plank": {Data: []byte("plot [1 2]\ninclude \"missing.plank\"")},
+		}, ErrInvalidInclude, "main.plank", 1, 0},
+		"escaping": {fstest.MapFS{
+			"main.plank": {Data: []byte("include \"../main.plank\"")},
+		}, ErrInvalidInclude, "main.plank", 0, 0},
+		"cycle": {fstest.MapFS{
+			"main.plank": {Data: []byte("include \"a.plank\"")},
+			"a.plank":    {Data: []byte("\ninclude \"main.plank\"")},
+		}, ErrIncludeCycle, "a.plank", 1, 0},
+		"figures": {fstest.MapFS{
+			"main.plank": {Data: []byte("include \"a.plank\"")},
+			"a.plank":    {Data: []byte("plot [1 2]\n---\nplot [3 4]")},
+		}, ErrInvalidInclude, "a.plank", 1, 0},
+		"argument": {fstest.MapFS{
+			"main.plank": {Data: []byte("include common")},
+		}, ErrInvalidInclude, "main.plank", 0, 0},
+	} {
+		_, err := compileInclude(tc.fsys, "main.plank")
+		var located *errorshelper.Error
+		if !errors.Is(err, tc.err) || !errors.As(err, &located) {
+			t.Error("Expected", tc.err, "for", name, "got", err)
+			continue
+		}
+		if located.File != tc.file || located.Line != tc.line || located.Column != tc.column {
+			t.Error("Expected", tc.file, tc.line, tc.column, "for", name, "got", located.File, located.Line, located.Column)
+		}
+	}
+
+	_, err := compileInclude(fstest.MapFS{"main.plank": {Data: []byte("include common")}}, "main.plank")
+	var te *types.TypeError
+	if !errors.As(err, &te) || Code(err) != "P0014" {
+		t.Error("Expected a type error with the code P0014, got", err)
+	}
+	_, err = compileInclude(fstest.MapFS{"main.plank": {Data: []byte("include \"main.plank\"")}}, "main.plank")
+	if Code(err) != "P0015" || err.Error() != "main.plank: line 1, column 1: invalid include\ninclude cycle\nmain.plank includes main.plank" {
+		t.Error("Expected the cycle of main.plank, got", err)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	lex, err := (&lexer.Config{File: "doc/main.plank"}).Lex(context.Background(), "include \"style/a.plank\"\nplot $x\ninclude \"../b.plank\" | color red\ninclude \"/abs.plank\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := Includes(lex)
+	if strings.Join(names, " ") != "doc/style/a.plank b.plank /abs.plank" {
+		t.Error("Expected the included files resolved from doc, got", names)
+	}
+}
+
+func TestDiagnoseFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.plank": {Data: []byte("include \"a.plank\"\nplot [1 2]")},
+		"a.plank":    {Data: []byte("let x 1\nplot [1 2] | colr red")},
+	}
+	fset := token.NewFileSet()
+	lex, err := (&lexer.Config{File: "main.plank", FileSet: fset}).Lex(context.Background(), string(fsys["main.plank"].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := (&Config{FS: fsys, File: "main.plank"}).Parse(context.Background(), lex)
+	if err == nil {
+		err = tree.Eval()
+	}
+	if fset.Lookup("a.plank") == nil {
+		t.Fatal("Expected the included file in the set of the document")
+	}
+	d := DiagnoseFiles(err, fset)
+	l, ok := d.Primary()
+	if !ok || l.File != "a.plank" || l.Line != 1 || l.Column != 13 || l.EndColumn != 17 {
+		t.Fatal("Expected colr in a.plank, got", l)
+	}
+	var b strings.Builder
+	if err := d.RenderFiles(&b, fset, false); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); !strings.Contains(out, "--> a.plank:2:14") || !strings.Contains(out, "2 | plot [1 2] | colr red") {
+		t.Error("Expected the diagnostic in a.plank, got", out)
+	}
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/planklang/goplank/errorshelper"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"slices"
+	"strings"
+)
+
+// Warning is a suspicious part of a document, which is not an error.
+type Warning struct {
+	Code    string // e.g. W0001
+	Pos     lexer.Position
+	Message string
+	Related []Related
+}
+
+// Related is another part of the document a warning is about.
+type Related struct {
+	Pos     lexer.Position
+	Message string
+}
+
+// lints are the codes of the warnings, explained in the catalog like the codes of the errors.
+var lints = []ErrorCode{
+	{"W0001", nil, "unused variable", "use the variable as $name, or remove its definition"},
+	{"W0002", nil, "overridden default", "remove the default, or the modifiers set again by the later defaults"},
+	{"W0003", nil, "overwrite without target", "overwrite modifies the statements before it in its figure, move it after them"},
+	{"W0004", nil, "modifier set twice", "remove one of the modifiers, only the last one is kept"},
+	{"W0005", nil, "empty figure", "remove the figure delimiter or add statements to the figure"},
+}
+
+// ignoreDirective starts the comments suppressing the warnings of their line, or of the next line when they are
+// alone on theirs: # plank:ignore W0001 W0004, or # plank:ignore for every warning.
+// ignoreFileDirective suppresses them in the whole document.
+const (
+	ignoreDirective     = "plank:ignore"
+	ignoreFileDirective = "plank:ignore-file"
+)
+
+// Lint returns the warnings of tree, a document evaluated without error, in the order of the source. lex are the
+// tokens of the document, whose comments may suppress warnings.
+func Lint(tree *Ast, lex *lexer.TokenList) []*Warning {
+	l := &linter{lets: make(map[string]*Statement), used: make(map[*Statement]bool), pending: make(map[string][]*pendingDefault)}
+	for i, fig := range tree.Body {
+		if len(fig.Stmts) == 0 && len(tree.Body) > 1 {
+			msg := "the figure has no statement"
+			if i == len(tree.Body)-1 {
+				msg = "the figure delimiter ends the document"
+			}
+			l.warn("W0005", fig.Pos, msg)
+		}
+		l.figure(fig)
+	}
+	for _, stmt := range l.lets {
+		l.unused(stmt)
+	}
+	// the included files are linted when they are checked themselves
+	l.warnings = slices.DeleteFunc(l.warnings, func(w *Warning) bool { return w.Pos.File != lex.File().Name() })
+	slices.SortStableFunc(l.warnings, func(a, b *Warning) int {
+		if a.Pos.Line != b.Pos.Line {
+			return a.Pos.Line - b.Pos.Line
+		}
+		return a.Pos.Column - b.Pos.Column
+	})
+	return suppress(l.warnings, lex)
+}
+
+// Diagnostic returns the diagnostic of w, a warning of the source src.
+func (w *Warning) Diagnostic(src string) *errorshelper.Diagnostic {
+	d := &errorshelper.Diagnostic{Severity: errorshelper.SeverityWarning, Code: w.Code, Message: w.Message}
+	for _, c := range lints {
+		if c.Code == w.Code {
+			d.Message, d.Help = c.Title, []string{c.Help}
+		}
+	}
+	lines := strings.Split(src, "\n")
+	label := func(pos lexer.Position, msg string, primary bool) {
+		if pos.Line < len(lines) {
+			s := span(lines[pos.Line], pos.Line, pos.Column)
+			s.File = pos.File
+			d.Labels = append(d.Labels, errorshelper.Label{Span: s, Message: msg, Primary: primary})
+		}
+	}
+	label(w.Pos, w.Message, true)
+	for _, r := range w.Related {
+		label(r.Pos, r.Message, false)
+	}
+	if len(d.Labels) == 0 { // no label to give the details
+		d.Message += ": " + w.Message
+	}
+	return d
+}
+
+type linter struct {
+	warnings []*Warning
+	lets     map[string]*Statement // the last definition of each variable
+	used     map[*Statement]bool   // the let statements whose variable is used
+	pending  map[string][]*pendingDefault
+}
+
+// pendingDefault is a default which did not apply to a statement yet, with its modifiers not set again by a later
+// default.
+type pendingDefault struct {
+	stmt *Statement
+	mods []string
+}
+
+func (l *linter) warn(code string, pos lexer.Position, msg string, related ...Related) {
+	l.warnings = append(l.warnings, &Warning{Code: code, Pos: pos, Message: msg, Related: related})
+}
+
+func (l *linter) figure(fig *Figure) {
+	count := make(map[string]int) // of the statements of each keyword before the current one in the figure
+	for _, stmt := range fig.Stmts {
+		l.uses(stmt.Arguments)
+		for _, m := range stmt.Modifiers {
+			l.uses(m.Arguments)
+		}
+		l.modifiersSetTwice(stmt)
+
+		target := ""
+		if stmt.Arguments != nil && len(stmt.Arguments.GetValues()) > 0 {
+			if v, ok := types.Unwrap(stmt.Arguments.GetValues()[0]).Value().(string); ok {
+				target = v
+			}
+		}
+		switch stmt.Keyword {
+		case KeywordLet:
+			if target != "" {
+				if prev, ok := l.lets[target]; ok {
+					l.unused(prev)
+				}
+				l.lets[target] = stmt
+			}
+		case KeywordPlot, KeywordAxis:
+			delete(l.pending, stmt.Keyword) // the defaults apply to the statement
+		case KeywordDefault:
+			l.overriddenDefaults(stmt, target)
+		case KeywordOverwrite, KeywordOw:
+			if count[target] == 0 {
+				l.warn("W0003", stmt.Pos, fmt.Sprintf("%s %s comes before any %s statement of its figure", stmt.Keyword, target, target))
+			}
+		}
+		count[stmt.Keyword]++
+	}
+}
+
+// uses marks the variables used by v.
+func (l *linter) uses(v types.Value) {
+	switch v := v.(type) {
+	case *Variable:
+		if stmt, ok := l.lets[v.Name]; ok {
+			l.used[stmt] = true
+		}
+	case *types.Tuple:
+		if v != nil { // no arguments
+			for _, x := range *v {
+				l.uses(x)
+			}
+		}
+	case *types.List:
+		for _, x := range v.GetValues() {
+			l.uses(x)
+		}
+	}
+}
+
+func (l *linter) unused(stmt *Statement) {
+	if l.used[stmt] {
+		return
+	}
+	name := types.Unwrap(stmt.Arguments.GetValues()[0]).Value().(string)
+	l.warn("W0001", stmt.Pos, fmt.Sprintf("$%s is never used", name))
+}
+
+func (l *linter) modifiersSetTwice(stmt *Statement) {
+	first := make(map[string]*Modifier)
+	for _, m := range stmt.Modifiers {
+		if f, ok := first[m.Name]; ok {
+			l.warn("W0004", m.Pos, fmt.Sprintf("%s is set again", m.Name), Related{f.Pos, "first set here"})
+			continue
+		}
+		first[m.Name] = m
+	}
+}
+
+// overriddenDefaults records the default stmt of target and reports the pending defaults it finishes to override.
+func (l *linter) overriddenDefaults(stmt *Statement, target string) {
+	var names []string
+	for _, m := range stmt.Modifiers {
+		names = append(names, m.Name)
+	}
+	var pending []*pendingDefault
+	for _, d := range l.pending[target] {
+		d.mods = slices.DeleteFunc(d.mods, func(name string) bool { return slices.Contains(names, name) })
+		if len(d.mods) > 0 {
+			pending = append(pending, d)
+			continue
+		}
+		msg := fmt.Sprintf("every modifier of this default is set again before any %s statement", target)
+		l.warn("W0002", d.stmt.Pos, msg, Related{stmt.Pos, "overridden by this default"})
+	}
+	if len(names) > 0 {
+		pending = append(pending, &pendingDefault{stmt, names})
+	}
+	l.pending[target] = pending
+}
+
+// suppress returns the warnings not suppressed by the directives in the comments of lex.
+func suppress(warnings []*Warning, lex *lexer.TokenList) []*Warning {
+	ignored := make(map[int][]string) // codes by line, nil for every code
+	var file []string
+	everyFile := false
+	for _, c := range lex.Comments() {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Literal, "#"))
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case ignoreFileDirective:
+			file = append(file, fields[1:]...)
+			everyFile = everyFile || len(fields) == 1
+		case ignoreDirective:
+			line := lex.Position(c.Pos).Line
+			alone := !slices.ContainsFunc(lex.Tokens(), func(t *lexer.Lexer) bool { return lex.Position(t.Pos).Line == line })
+			if alone { // the comment is about the next line
+				line++
+			}
+			if len(fields) == 1 {
+				ignored[line] = nil
+			} else if codes, ok := ignored[line]; !ok || codes != nil {
+				ignored[line] = append(codes, fields[1:]...)
+			}
+		}
+	}
+	return slices.DeleteFunc(warnings, func(w *Warning) bool {
+		if everyFile || slices.Contains(file, w.Code) {
+			return true
+		}
+		codes, ok := ignored[w.Pos.Line]
+		return ok && (codes == nil || slices.Contains(codes, w.Code))
+	})
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"github.com/planklang/goplank/lexer"
+	"slices"
+	"testing"
+)
+
+func lint(t *testing.T, src string) []*Warning {
+	t.Helper()
+	lex, err := lexer.Lex(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Parse(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tree.Eval(); err != nil {
+		t.Fatal(err)
+	}
+	return Lint(tree, lex)
+}
+
+func lintCodes(warns []*Warning) []string {
+	var res []string
+	for _, w := range warns {
+		res = append(res, w.Code)
+	}
+	return res
+}
+
+func TestLint(t *testing.T) {
+	for src, want := range map[string][]string{
+		"let a [1 2]\nplot $a":                                            nil,
+		"let a [1 2]\nlet a [3 4]\nplot $a":                               {"W0001"},
+		"let a [1 2]\nlet a $a\nplot $a":                                  nil,
+		"let a [1 2]\nplot [1 2]\n---\nplot $a":                           nil,
+		"default plot | color red\ndefault plot | color blue":             {"W0002"},
+		"default plot | color red | width 2\ndefault plot | color blue":   nil,
+		"default plot | color red\nplot [1 2]\ndefault plot | color blue": nil,
+		"default axis | label \"x\"\ndefault plot | label \"y\"":          nil,
+		"ow plot | color red\nplot [1 2]":                                 {"W0003"},
+		"plot [1 2]\n---\noverwrite plot | color red":                     {"W0003"},
+		"plot [1 2]\noverwrite plot | color red":                          nil,
+		"plot [1 2] | color red | width 2 | color blue":                   {"W0004"},
+		"plot [1 2]\n---\n---\nplot [3 4]":                                {"W0005"},
+		"plot [1 2]\n---":                                                 {"W0005"},
+		"plot [1 2]":                                                      nil,
+	} {
+		if got := lintCodes(lint(t, src)); !slices.Equal(got, want) {
+			t.Errorf("Expected %v for %q, got %v", want, src, got)
+		}
+	}
+
+	warns := lint(t, "plot [1 2] | color red | color blue")
+	if len(warns) != 1 || warns[0].Pos.Column != 25 || len(warns[0].Related) != 1 || warns[0].Related[0].Pos.Column != 13 {
+		t.Error("Expected the second color and the first one, got", warns)
+	}
+}
+
+func TestLintSuppress(t *testing.T) {
+	for src, want := range map[string][]string{
+		"let a [1 2] # plank:ignore\nplot [1 2]":                           nil,
+		"let a [1 2] # plank:ignore W0001\nplot [1 2]":                     nil,
+		"let a [1 2] # plank:ignore W0004\nplot [1 2]":                     {"W0001"},
+		"# plank:ignore W0001\nlet a [1 2]\nplot [1 2]":                    nil,
+		"# plank:ignore W0001\nplot [1 2]\nlet a [1 2]":                    {"W0001"},
+		"plot [1 2]\n# plank:ignore\nlet a [1 2]":                          nil,
+		"plot [1 2] # plank:ignore\nlet a [1 2]":                           {"W0001"},
+		"# plank:ignore-file W0001\nlet a [1 2]\nlet b [1 2]\nplot [1 2]":  nil,
+		"# plank:ignore-file\nlet a [1 2]\nplot [1 2] | width 1 | width 2": nil,
+		"# plank:ignore-file W0004\nlet a [1 2]\nplot [1 2]":               {"W0001"},
+	} {
+		if got := lintCodes(lint(t, src)); !slices.Equal(got, want) {
+			t.Errorf("Expected %v for %q, got %v", want, src, got)
+		}
+	}
+}
+
+func TestWarningDiagnostic(t *testing.T) {
+	src := "let a [1 2]\nplot [1 2] | color red | color blue"
+	var got []string
+	for _, w := range lint(t, src) {
+		d := w.Diagnostic(src)
+		if d.Severity.String() != "warning" || len(d.Labels) == 0 {
+			t.Error("Expected a located warning, got", d)
+		}
+		got = append(got, d.Code+" "+d.Message)
+	}
+	if want := []string{"W0001 unused variable", "W0004 modifier set twice"}; !slices.Equal(got, want) {
+		t.Error("Expected", want, "got", got)
+	}
+}
@@ -1,44 +1,229 @@
 package parser
 
-import "github.com/planklang/goplank/parser/types"
+import (
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"image/color"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+)
 
 type Modifier struct {
 	Name      string
 	Arguments *types.Tuple
+	Pos       lexer.Position
 }
 
-//type ModifierColor struct {
-//	*color.RGBA
-//}
-//
-//func (c *ModifierColor) Modify(s Statement) error {
-//	return nil
-//}
-//
-//func (c *ModifierColor) SetArgument(t *types.Tuple) error {
-//	if !c.ValidArgument(t.Type()) {
-//		return errors.Join(ErrInvalidModifier, fmt.Errorf("cannot apply argument %v to modifier %s", t, c))
-//	}
-//	val := t.GetValues()
-//	c.RGBA = &color.RGBA{
-//		R: uint8(val[0].Value().(int)),                       // inferred by ValidArgument
-//		G: uint8(val[1].Value().(int)),                       // inferred by ValidArgument
-//		B: uint8(val[2].Value().(int)),                       // inferred by ValidArgument
-//		A: uint8(math.Floor(val[3].Value().(float64) * 255)), // inferred by ValidArgument
-//	}
-//	return nil
-//}
-//
-//func (c *ModifierColor) ValidArgument(t types.Type) bool {
-//	return t.Is(types.NewTupleType(types.IntType, types.IntType, types.IntType, types.FloatType))
-//}
-//
-//func (c *ModifierColor) ValidStatement(s Statement) bool {
-//	//_, ok := s.(StatementPlot)
-//	//return ok
-//	return false
-//}
-//
-//func (c *ModifierColor) String() string {
-//	return fmt.Sprintf("color{%d %d %d %d}", c.R, c.G, c.B, c.A)
-//}
+func (m *Modifier) String() string {
+	return m.Name
+}
+
+type modifierFunc[T any] func(target T, arg *types.Tuple) error
+
+var axisModifiers = map[string]modifierFunc[*Axis]{
+	"label": func(a *Axis, arg *types.Tuple) error {
+		s, err := stringArgument(arg)
+		a.Label = s
+		return err
+	},
+	"range": func(a *Axis, arg *types.Tuple) error {
+		values := arg.GetValues()
+		if len(values) != 1 {
+			return errors.Join(ErrInvalidArgument, fmt.Errorf("range expects a list of two numbers"))
+		}
+		l, ok := values[0].(*types.List)
+		if !ok {
+			return errors.Join(ErrInvalidArgument, fmt.Errorf("range expects a list of two numbers"))
+		}
+		r, err := parseRange(l)
+		a.Range = r
+		return err
+	},
+	"scale": func(a *Axis, arg *types.Tuple) error {
+		s, err := choiceArgument(arg, ScaleLinear, ScaleLog)
+		a.Scale = s
+		return err
+	},
+	"grid": func(a *Axis, arg *types.Tuple) error {
+		if len(arg.GetValues()) == 0 { // grid alone turns the grid on
+			a.Grid = true
+			return nil
+		}
+		s, err := choiceArgument(arg, "on", "off")
+		a.Grid = s == "on"
+		return err
+	},
+}
+
+var plotModifiers = map[string]modifierFunc[*Plot]{
+	"label": func(p *Plot, arg *types.Tuple) error {
+		s, err := stringArgument(arg)
+		p.Label = s
+		return err
+	},
+	"color": func(p *Plot, arg *types.Tuple) error {
+		c, err := parseColor(arg)
+		p.Color = c
+		return err
+	},
+	"width": func(p *Plot, arg *types.Tuple) error {
+		f, err := floatArgument(arg)
+		if err == nil && f <= 0 {
+			err = errors.Join(ErrInvalidArgument, fmt.Errorf("width must be positive, not %g", f))
+		}
+		p.Width = f
+		return err
+	},
+	"style": func(p *Plot, arg *types.Tuple) error {
+		s, err := choiceArgument(arg, StyleLine, StyleScatter, StyleBar)
+		p.Style = s
+		return err
+	},
+	"dash": func(p *Plot, arg *types.Tuple) error {
+		d, err := choiceArgument(arg, DashSolid, DashDashed, DashDotted)
+		p.Dash = d
+		return err
+	},
+	"marker": func(p *Plot, arg *types.Tuple) error {
+		m, err := choiceArgument(arg, MarkerNone, MarkerCircle, MarkerSquare, MarkerTriangle, MarkerDiamond, MarkerPlus)
+		p.Marker = m
+		return err
+	},
+}
+
+// ModifierNames returns the sorted names of the modifiers accepted by the statement keyword.
+func ModifierNames(keyword string) []string {
+	switch keyword {
+	case KeywordAxis:
+		return slices.Sorted(maps.Keys(axisModifiers))
+	case KeywordPlot:
+		return slices.Sorted(maps.Keys(plotModifiers))
+	}
+	return nil
+}
+
+func applyModifiers[T fmt.Stringer](target T, table map[string]modifierFunc[T], mods []*Modifier) error {
+	for _, m := range mods {
+		fn, ok := table[m.Name]
+		if !ok {
+			sugg := suggestion(m.Name, slices.Sorted(maps.Keys(table)))
+			return locate(errors.Join(ErrUnknownModifier, sugg, fmt.Errorf("cannot apply modifier %s to statement %s", m, target)), m.Pos)
+		}
+		arg := m.Arguments
+		if arg == nil {
+			arg = new(types.Tuple)
+		}
+		if err := fn(target, arg); err != nil {
+			return locate(errors.Join(ErrInvalidModifier, fmt.Errorf("modifier %s", m), err), m.Pos)
+		}
+	}
+	return nil
+}
+
+func stringArgument(arg *types.Tuple) (string, error) {
+	v, ok := arg.Cast(types.StringType)
+	if !ok || len(arg.GetValues()) != 1 {
+		return "", &types.TypeError{Want: types.StringType, Got: types.Unwrap(arg).Type(), Err: ErrInvalidArgument}
+	}
+	return v.Value().(string), nil
+}
+
+func floatArgument(arg *types.Tuple) (float64, error) {
+	v, ok := arg.Cast(types.FloatType)
+	if !ok {
+		return 0, &types.TypeError{Want: types.FloatType, Got: types.Unwrap(arg).Type(), Err: ErrInvalidArgument}
+	}
+	return v.Value().(float64), nil
+}
+
+func choiceArgument[T ~string](arg *types.Tuple, choices ...T) (T, error) {
+	values := arg.GetValues()
+	if len(values) == 1 && types.Unwrap(values[0]).Type().Is(types.DefaultLiteralType) {
+		s := T(types.Unwrap(values[0]).Value().(string))
+		if slices.Contains(choices, s) {
+			return s, nil
+		}
+	}
+	return "", errors.Join(ErrInvalidArgument, fmt.Errorf("expected one of %v", choices))
+}
+
+var namedColors = map[string]color.RGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"gray":    {128, 128, 128, 255},
+	"red":     {255, 0, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"orange":  {255, 165, 0, 255},
+	"purple":  {128, 0, 128, 255},
+	"cyan":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"brown":   {165, 42, 42, 255},
+	"pink":    {255, 192, 203, 255},
+}
+
+func parseColor(arg *types.Tuple) (*color.RGBA, error) {
+	// color = name | "#rrggbb" | "#rrggbbaa" | ( int, int, int, [ float ] )
+	values := arg.GetValues()
+	if len(values) == 1 {
+		v := types.Unwrap(values[0])
+		if v.Type().Is(types.DefaultLiteralType) {
+			c, ok := namedColors[v.Value().(string)]
+			if !ok {
+				return nil, errors.Join(ErrInvalidArgument, fmt.Errorf("unknown color %s", v.Value()))
+			}
+			return &c, nil
+		}
+		if v.Type().Is(types.StringType) {
+			return parseHexColor(v.Value().(string))
+		}
+	}
+	if len(values) != 3 && len(values) != 4 {
+		return nil, errors.Join(ErrInvalidArgument, fmt.Errorf("invalid color %v", arg.Value()))
+	}
+	c := &color.RGBA{A: 255}
+	for i, p := range []*uint8{&c.R, &c.G, &c.B} {
+		v := types.Unwrap(values[i])
+		if !v.Type().Is(types.IntType) {
+			ctx := fmt.Sprintf("color component %v", v.Value())
+			return nil, &types.TypeError{Want: types.IntType, Got: v.Type(), Context: ctx, Err: ErrInvalidArgument}
+		}
+		n := v.Value().(int)
+		if n < 0 || n > 255 {
+			return nil, errors.Join(ErrInvalidArgument, fmt.Errorf("color component %d is not in [0, 255]", n))
+		}
+		*p = uint8(n)
+	}
+	if len(values) == 4 {
+		v, ok := values[3].Cast(types.FloatType)
+		if !ok {
+			ctx := fmt.Sprintf("color alpha %v", values[3].Value())
+			return nil, &types.TypeError{Want: types.FloatType, Got: types.Unwrap(values[3]).Type(), Context: ctx, Err: ErrInvalidArgument}
+		}
+		a := v.Value().(float64)
+		if a < 0 || a > 1 {
+			return nil, errors.Join(ErrInvalidArgument, fmt.Errorf("color alpha %g is not in [0, 1]", a))
+		}
+		c.A = uint8(math.Round(a * 255))
+	}
+	return c, nil
+}
+
+func parseHexColor(s string) (*color.RGBA, error) {
+	if (len(s) != 7 && len(s) != 9) || s[0] != '#' {
+		return nil, errors.Join(ErrInvalidArgument, fmt.Errorf("invalid color %q", s))
+	}
+	n, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidArgument, fmt.Errorf("invalid color %q", s))
+	}
+	if len(s) == 7 {
+		n = n<<8 | 0xff
+	}
+	return &color.RGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: uint8(n)}, nil
+}
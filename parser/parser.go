@@ -1,10 +1,16 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"github.com/planklang/goplank/errorshelper"
 	"github.com/planklang/goplank/lexer"
 	"github.com/planklang/goplank/parser/types"
+	"github.com/planklang/goplank/token"
+	"io/fs"
+	"maps"
+	"slices"
 	"strconv"
 )
 
@@ -17,77 +23,174 @@ var (
 	ErrMissingLiteral    = errors.New("missing literal")
 	ErrUnexpectedToken   = errors.New("unexpected token")
 	ErrDelimiterExcepted = errors.Join(ErrUnexpectedToken, errors.New("delimiter excepted"))
+	ErrKeywordExpected   = errors.Join(ErrUnexpectedToken, errors.New("keyword expected"))
+	ErrModifierExpected  = errors.Join(ErrUnexpectedToken, errors.New("modifier expected"))
+	ErrUnknownModifier   = errors.Join(ErrInvalidModifier, errors.New("unknown modifier"))
 )
 
+// Config configures the parsing of a document.
+type Config struct {
+	// Variables are defined before the first statement, as if by let statements.
+	Variables map[string]types.Value
+	// MaxDepth is the maximum number of nested containers, e.g. 2 for [(1 2) (3 4)]. 0 means no limit.
+	MaxDepth int
+	// MaxSourceSize is the maximum size in bytes of each included file. 0 means no limit.
+	MaxSourceSize int
+	// MaxTokens is the maximum number of tokens of the document and its included files together, counted again at
+	// each include of a file. 0 means no limit.
+	MaxTokens int
+	// FS is the file system of the files included by the document. No file is read when it is nil: vfs.OS reads any
+	// file and vfs.Root the files under a directory.
+	FS fs.FS
+	// File is the name of the document in FS, the included files are relative to its directory.
+	File string
+	// SyntaxOnly checks the syntax only, for tools like formatters: the variables are not resolved, each use holds its
+	// name as a default literal, the values of the lists are not type checked and the included files are not read.
+	SyntaxOnly bool
+}
+
 func Parse(lex *lexer.TokenList) (*Ast, error) {
-	// top-level = [ figure, [{ figure-delimiter, [figure] }] ];
+	return new(Config).Parse(context.Background(), lex)
+}
 
-	tree := new(Ast)
-	tree.Type = AstTypeDefault
+// Parse parses the tokens of a document with the configuration c. It stops with the error of ctx when ctx is done.
+// The files included by the document are registered in the file set of lex.
+func (c *Config) Parse(ctx context.Context, lex *lexer.TokenList) (*Ast, error) {
+	st := &state{ctx: ctx, conf: c, fset: lex.FileSet(), vars: make(variables), files: []string{c.File}, tokens: len(lex.Tokens())}
+	maps.Copy(st.vars, c.Variables)
+	return st.parse(lex)
+}
 
-	fig, err := parseFigure(lex) // before the first [TokenList.Next] because top level does not require something to start
+// state is the state of the parsing of a document.
+type state struct {
+	ctx      context.Context
+	conf     *Config
+	fset     *token.FileSet    // of the document, where the included files are registered
+	vars     variables         // shared by the figures, like the defaults
+	depth    int               // of the current container
+	files    []string          // the document, then the files included by the previous one
+	includes int               // the number of files included so far, bounded by maxIncludes
+	tokens   int               // of the document and of the files included so far
+	sources  map[string]string // the included files already read, by name
+}
+
+// parse parses the tokens of the document, or of a file it includes, and locates the errors.
+func (st *state) parse(lex *lexer.TokenList) (*Ast, error) {
+	tree, err := parse(lex, st)
+	if err != nil && err == st.ctx.Err() { // not located, it does not come from the source
+		return nil, err
+	}
 	if err != nil {
+		tok := lex.Current()
+		if tok == nil { // the error happened at the end of the tokens
+			tok = lex.Last()
+		}
+		if tok != nil {
+			err = locate(err, lex.Position(tok.Pos))
+		}
 		return nil, err
 	}
-	tree.Body = append(tree.Body, fig)
+	return tree, nil
+}
 
-	for lex.Next() {
-		fig, err = parseFigure(lex)
+func parse(lex *lexer.TokenList, st *state) (*Ast, error) {
+	// top-level = [ figure, [{ figure-delimiter, [figure] }] ];
+
+	tree := new(Ast)
+	tree.Type = AstTypeDefault
+
+	pos := lexer.Position{File: lex.File().Name()} // of the first figure, at the start of the source
+	for {
+		fig, err := parseFigure(lex, st)
 		if err != nil {
 			return nil, err
 		}
+		fig.Pos = pos
 		tree.Body = append(tree.Body, fig)
 
-		if !lex.Next() {
+		if lex.Empty() {
 			return tree, nil
 		}
 		if lex.Current().Type != lexer.FigureDelimiterType {
-			return nil, errors.Join(ErrDelimiterExcepted, fmt.Errorf("expected figure delimiter, not %s", lex.Current()))
+			return nil, syntaxError(lex, "figure delimiter", ErrDelimiterExcepted)
 		}
+		pos = lex.Position(lex.Current().Pos)
 	}
-
-	return tree, nil
 }
 
-func parseFigure(lex *lexer.TokenList) (*Figure, error) {
+func parseFigure(lex *lexer.TokenList, st *state) (*Figure, error) {
 	// figure = [ statement, [{ statement-delimiter, [ statement ] }] ];
 
 	fig := new(Figure)
 
 	for lex.Next() {
-		stmt, err := parseStatement(lex)
+		if err := st.ctx.Err(); err != nil {
+			return fig, err
+		}
+		switch lex.Current().Type {
+		case lexer.FigureDelimiterType:
+			return fig, nil
+		case lexer.StatementDelimiterType: // empty statement, e.g. after a figure delimiter
+			continue
+		}
+
+		stmt, err := parseStatement(lex, st)
 		if err != nil {
 			return fig, err
 		}
+		var included []*Statement
+		switch stmt.Keyword {
+		case KeywordLet:
+			if err = st.vars.define(stmt); err != nil {
+				return fig, locate(err, stmt.Pos)
+			}
+		case KeywordInclude:
+			if st.conf.SyntaxOnly {
+				break
+			}
+			if included, err = st.include(stmt); err != nil {
+				if err != st.ctx.Err() {
+					err = locate(err, stmt.Pos)
+				}
+				return fig, err
+			}
+		}
 		fig.Stmts = append(fig.Stmts, stmt)
+		fig.Stmts = append(fig.Stmts, included...)
 
-		if !lex.Next() {
+		// parseStatement stops on the token following the statement
+		if lex.Empty() || lex.Current().Type == lexer.FigureDelimiterType {
 			return fig, nil
 		}
 		if lex.Current().Type != lexer.StatementDelimiterType {
-			return nil, errors.Join(ErrDelimiterExcepted, fmt.Errorf("expected statement delimiter, not %s", lex.Current()))
+			return nil, syntaxError(lex, "statement delimiter", ErrDelimiterExcepted)
 		}
 	}
 
 	return fig, nil
 }
 
-func parseStatement(lex *lexer.TokenList) (*Statement, error) {
+func parseStatement(lex *lexer.TokenList, st *state) (*Statement, error) {
 	// statement = keyword, [ arguments ], [{ property-delimiter, property }];
 
 	if lex.Current().Type != lexer.KeywordType {
-		return nil, errors.Join(ErrUnexpectedToken, fmt.Errorf("expected keyword, not %s", lex.Current()))
+		var sugg error
+		if lex.Current().Type == lexer.IdentifierType {
+			sugg = suggestion(lex.Current().Literal, lexer.Keywords())
+		}
+		return nil, syntaxError(lex, "keyword", errors.Join(ErrKeywordExpected, sugg))
 	}
 
 	stmt := new(Statement)
 	stmt.Keyword = lex.Current().Literal
+	stmt.Pos = lex.Position(lex.Current().Pos)
 
 	if !lex.Next() {
 		return stmt, nil
 	}
 
 	if lex.Current().Type != lexer.ModifierDelimiterType {
-		args, err := parseArgument(lex)
+		args, err := parseArgument(lex, st)
 		if err != nil {
 			return nil, err
 		}
@@ -98,12 +201,12 @@ func parseStatement(lex *lexer.TokenList) (*Statement, error) {
 		return stmt, nil
 	}
 
-	for lex.Current().Type == lexer.ModifierDelimiterType {
+	for !lex.Empty() && lex.Current().Type == lexer.ModifierDelimiterType {
 		if !lex.Next() {
-			return nil, errors.Join(lexer.ErrInvalidExpression, fmt.Errorf("expected modifier definition after modifier delimiter"))
+			return nil, syntaxError(lex, "modifier name", errors.Join(ErrModifierExpected, lexer.ErrInvalidExpression))
 		}
 
-		mod, err := parseProperty(lex)
+		mod, err := parseProperty(lex, st)
 		if err != nil {
 			return nil, err
 		}
@@ -113,21 +216,22 @@ func parseStatement(lex *lexer.TokenList) (*Statement, error) {
 	return stmt, nil
 }
 
-func parseProperty(lex *lexer.TokenList) (*Modifier, error) {
+func parseProperty(lex *lexer.TokenList, st *state) (*Modifier, error) {
 	// property = ? identifier ?, [ arguments ]
 
 	if lex.Current().Type != lexer.IdentifierType {
-		return nil, errors.Join(ErrUnexpectedToken, fmt.Errorf("expected modifier name, not %v", lex.Current()))
+		return nil, syntaxError(lex, "modifier name", ErrModifierExpected)
 	}
 
 	mod := new(Modifier)
 	mod.Name = lex.Current().Literal
+	mod.Pos = lex.Position(lex.Current().Pos)
 
 	if !lex.Next() {
 		return mod, nil
 	}
 
-	args, err := parseArgument(lex)
+	args, err := parseArgument(lex, st)
 	if err != nil {
 		return nil, err
 	}
@@ -135,21 +239,23 @@ func parseProperty(lex *lexer.TokenList) (*Modifier, error) {
 	return mod, nil
 }
 
-func parseArgument(lex *lexer.TokenList) (*types.Tuple, error) {
+func parseArgument(lex *lexer.TokenList, st *state) (*types.Tuple, error) {
 
 	tuple := new(types.Tuple)
 
 	for lex.Current().Type != lexer.StatementDelimiterType &&
 		lex.Current().Type != lexer.ModifierDelimiterType &&
 		lex.Current().Type != lexer.FigureDelimiterType { // do not call [TokenList.Next] because argument does not require anything
-		val, err := parseWeakDelimiters(lex)
+		val, err := parseWeakDelimiters(lex, st)
 		if err != nil {
 			return nil, err
 		}
-		tuple.AddValues(val)
+		if err = tuple.AddValues(val); err != nil {
+			return nil, err
+		}
 
 		if !lex.Next() { // call [TokenList.Next] here because parseWeakDelimiters never skips the last one
-			return tuple, nil
+			break
 		}
 	}
 
@@ -163,25 +269,33 @@ func parseArgument(lex *lexer.TokenList) (*types.Tuple, error) {
 	return tuple, nil
 }
 
-func parseWeakDelimiters(lex *lexer.TokenList) (types.Value, error) {
+func parseWeakDelimiters(lex *lexer.TokenList, st *state) (types.Value, error) {
 	if lex.Current().Type != lexer.WeakDelimiterType {
-		return parseLiteral(lex.Current())
+		return parseLiteral(lex.Current(), st)
 	}
 	fn := func(c types.ValueContainer, end string) error {
+		st.depth++
+		defer func() { st.depth-- }()
+		if max := st.conf.MaxDepth; max > 0 && st.depth > max {
+			return &errorshelper.LimitError{Limit: "nested containers", Max: max}
+		}
 		for lex.Next() && (lex.Current().Type != lexer.WeakDelimiterType || lex.Current().Literal != end) {
 			if lex.Current().Type == lexer.ModifierDelimiterType ||
 				lex.Current().Type == lexer.FigureDelimiterType ||
 				lex.Current().Type == lexer.StatementDelimiterType {
-				return errors.Join(ErrMissingLiteral, fmt.Errorf("unfinished container %v", c))
+				return syntaxError(lex, end, errors.Join(ErrMissingLiteral, lexer.ErrUnclosedContainer))
 			}
-			val, err := parseWeakDelimiters(lex)
+			val, err := parseWeakDelimiters(lex, st)
 			if err != nil {
 				return err
 			}
-			if !c.CanContain(val) {
-				return errors.Join(ErrInvalidLiteral, fmt.Errorf("container cannot contain %v", val))
+			if l, ok := c.(*types.List); ok && st.conf.SyntaxOnly { // the types of the variables are unknown
+				l.Append(val)
+				continue
+			}
+			if err = c.AddValues(val); err != nil { // a *types.TypeError
+				return errors.Join(ErrInvalidLiteral, err)
 			}
-			c.AddValues(val)
 		}
 		return nil
 	}
@@ -193,31 +307,71 @@ func parseWeakDelimiters(lex *lexer.TokenList) (types.Value, error) {
 		list := new(types.List)
 		return list, fn(list, "]") // valid because list is a pointer
 	case "]", ")":
-		return nil, errors.Join(ErrInvalidLiteral, fmt.Errorf("cannot close a container with %s", lex.Current().Literal))
+		return nil, syntaxError(lex, "", errors.Join(ErrInvalidLiteral, lexer.ErrUnopenedContainer))
 	}
 	return nil, errors.Join(ErrUnknownValue, fmt.Errorf("unsupported weak delimiters %s", lex.Current().Type))
 }
 
-func parseLiteral(lex *lexer.Lexer) (types.Value, error) {
+func parseLiteral(lex *lexer.Lexer, st *state) (types.Value, error) {
 	switch lex.Type {
-	case lexer.IdentifierType:
+	case lexer.IdentifierType, lexer.KeywordType: // keywords are arguments of default and overwrite
 		return types.NewDefaultLiteral(lex.Literal), nil
 	case lexer.VariableType:
-		//TODO: handle
+		if st.conf.SyntaxOnly {
+			return &Variable{Name: lex.Literal, Pos: st.fset.Position(lex.Pos), Resolved: types.NewDefaultLiteral(lex.Literal)}, nil
+		}
+		v, ok := st.vars[lex.Literal]
+		if !ok {
+			var sugg error
+			if s, ok := errorshelper.Suggest(lex.Literal, slices.Sorted(maps.Keys(st.vars))); ok {
+				sugg = &errorshelper.SuggestionError{Word: "$" + lex.Literal, Suggestion: "$" + s}
+			}
+			return nil, errors.Join(ErrUndefinedVariable, sugg, fmt.Errorf("$%s is not defined", lex.Literal))
+		}
+		return &Variable{Name: lex.Literal, Pos: st.fset.Position(lex.Pos), Resolved: v}, nil
 	case lexer.StringType:
 		return types.String(lex.Literal), nil
 	case lexer.IntType:
 		i, err := strconv.ParseInt(lex.Literal, 10, 64)
-		if err != nil {
-			return nil, err
+		if err != nil { // the lexer only gives digits, so it is out of range
+			return nil, errors.Join(ErrInvalidLiteral, fmt.Errorf("%s is out of the range of the ints", lex.Literal))
 		}
 		return types.Int(i), nil
 	case lexer.FloatType:
 		f, err := strconv.ParseFloat(lex.Literal, 64)
-		if err != nil {
-			return nil, err
+		if err != nil { // the lexer only gives digits and a point, so it is out of range
+			return nil, errors.Join(ErrInvalidLiteral, fmt.Errorf("%s is out of the range of the floats", lex.Literal))
 		}
 		return types.Float(f), nil
 	}
 	return nil, errors.Join(ErrUnknownValue, fmt.Errorf("unsupported literal lex types %s", lex.Type))
 }
+
+// locate attaches pos to err, unless err is already located.
+func locate(err error, pos lexer.Position) error {
+	var located *errorshelper.Error
+	if errors.As(err, &located) {
+		return err
+	}
+	return &errorshelper.Error{File: pos.File, Offset: pos.Offset, Line: pos.Line, Column: pos.Column, Err: err}
+}
+
+// syntaxError returns the syntax error err about the current token of lex, or about the end of the source when
+// there is no current token. It is located at the token.
+func syntaxError(lex *lexer.TokenList, expected string, err error) error {
+	se := &lexer.SyntaxError{Expected: expected, Found: "end of source", Err: err}
+	if tok := lex.Current(); tok != nil {
+		se.Pos, se.Found = lex.Position(tok.Pos), tok.String()
+	} else if tok = lex.Last(); tok != nil {
+		se.Pos = lex.Position(tok.End)
+	}
+	return locate(se, se.Pos)
+}
+
+// suggestion returns a suggestion to join to the error about word if it looks like a typo of a candidate, or nil.
+func suggestion(word string, candidates []string) error {
+	if s, ok := errorshelper.Suggest(word, candidates); ok {
+		return &errorshelper.SuggestionError{Word: word, Suggestion: s}
+	}
+	return nil
+}
@@ -1,69 +1,198 @@
 package parser
 
-import "github.com/planklang/goplank/parser/types"
+import (
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"image/color"
+)
+
+const (
+	KeywordPlot      = "plot"
+	KeywordAxis      = "axis"
+	KeywordDefault   = "default"
+	KeywordOverwrite = "overwrite"
+	KeywordOw        = "ow"
+	KeywordLet       = "let"
+	KeywordInclude   = "include"
+)
 
 type Statement struct {
 	Keyword   string
 	Arguments *types.Tuple
 	Modifiers []*Modifier
+	Pos       lexer.Position
+}
+
+func (s *Statement) String() string {
+	return s.Keyword
+}
+
+type Scale string
+
+const (
+	ScaleLinear Scale = "linear"
+	ScaleLog    Scale = "log"
+)
+
+// Axis is the evaluated form of every axis statement targeting the same axis.
+type Axis struct {
+	Target string
+	Label  string
+	Range  [2]float64 // [0 0] when the range is automatic
+	Scale  Scale
+	Grid   bool
+}
+
+func newAxis(target string) *Axis {
+	return &Axis{Target: target, Scale: ScaleLinear}
+}
+
+func (a *Axis) HasRange() bool {
+	return a.Range[0] != a.Range[1]
+}
+
+func (a *Axis) SetArgument(arg *types.Tuple) error {
+	// arguments = target, [ label ], [ range ] (label and range in any order)
+	values := arg.GetValues()
+	for _, v := range values[1:] { // values[0] is the target, checked by the caller
+		v = types.Unwrap(v)
+		if v.Type().Is(types.StringType) {
+			a.Label = v.Value().(string)
+			continue
+		}
+		if l, ok := v.(*types.List); ok {
+			r, err := parseRange(l)
+			if err != nil {
+				return err
+			}
+			a.Range = r
+			continue
+		}
+		return errors.Join(ErrInvalidArgument, fmt.Errorf("cannot apply argument %v to statement %s", v.Value(), a))
+	}
+	return nil
+}
+
+func (a *Axis) String() string {
+	return fmt.Sprintf("axis{%s}", a.Target)
+}
+
+type Style string
+
+const (
+	StyleLine    Style = "line"
+	StyleScatter Style = "scatter"
+	StyleBar     Style = "bar"
+)
+
+type Dash string
+
+const (
+	DashSolid  Dash = "solid"
+	DashDashed Dash = "dashed"
+	DashDotted Dash = "dotted"
+)
+
+type Marker string
+
+const (
+	MarkerNone     Marker = "none"
+	MarkerCircle   Marker = "circle"
+	MarkerSquare   Marker = "square"
+	MarkerTriangle Marker = "triangle"
+	MarkerDiamond  Marker = "diamond"
+	MarkerPlus     Marker = "plus"
+)
+
+// Plot is the evaluated form of a plot statement: one series of the figure.
+type Plot struct {
+	X      []float64
+	Y      []float64
+	Label  string
+	Color  *color.RGBA `json:",omitempty"` // nil lets the backend choose
+	Width  float64     // 0 lets the backend choose
+	Style  Style
+	Dash   Dash
+	Marker Marker
+}
+
+func newPlot() *Plot {
+	return &Plot{Style: StyleLine, Dash: DashSolid, Marker: MarkerNone}
+}
+
+func (p *Plot) SetArgument(arg *types.Tuple) error {
+	// arguments = [ x-values ], y-values, [ label ]
+	var data [][]float64
+	for _, v := range arg.GetValues() {
+		v = types.Unwrap(v)
+		if v.Type().Is(types.StringType) {
+			p.Label = v.Value().(string)
+			continue
+		}
+		l, ok := v.(*types.List)
+		if !ok || len(data) == 2 {
+			return errors.Join(ErrInvalidArgument, fmt.Errorf("cannot apply argument %v to statement %s", v.Value(), p))
+		}
+		fs, err := toFloats(l)
+		if err != nil {
+			return err
+		}
+		data = append(data, fs)
+	}
+	switch len(data) {
+	case 0:
+		return errors.Join(ErrInvalidArgument, fmt.Errorf("statement %s requires data", p))
+	case 1:
+		p.Y = data[0]
+		p.X = make([]float64, len(p.Y))
+		for i := range p.X {
+			p.X[i] = float64(i)
+		}
+	case 2:
+		if len(data[0]) != len(data[1]) {
+			return errors.Join(ErrInvalidArgument, fmt.Errorf("x and y have different lengths (%d and %d)", len(data[0]), len(data[1])))
+		}
+		p.X, p.Y = data[0], data[1]
+	}
+	return nil
+}
+
+func (p *Plot) String() string {
+	if p.Label == "" {
+		return KeywordPlot
+	}
+	return fmt.Sprintf("plot{%s}", p.Label)
+}
+
+func toFloats(l *types.List) ([]float64, error) {
+	values := l.GetValues()
+	if len(values) == 0 {
+		return nil, errors.Join(ErrInvalidArgument, fmt.Errorf("empty list"))
+	}
+	res := make([]float64, len(values))
+	for i, v := range values {
+		f, ok := v.Cast(types.FloatType)
+		if !ok {
+			ctx := fmt.Sprintf("data %v", v.Value())
+			return nil, &types.TypeError{Want: types.FloatType, Got: v.Type(), Context: ctx, Err: ErrInvalidArgument}
+		}
+		res[i] = f.Value().(float64)
+	}
+	return res, nil
 }
 
-//type Axis struct {
-//	Target    string
-//	Label     string
-//	Range     [2]float64
-//	Modifiers []Modifier
-//}
-//
-//func (a *Axis) Eval() error {
-//	return nil
-//}
-//
-//func (a *Axis) AddModifier(m Modifier) error {
-//	if !m.ValidStatement(a) {
-//		return errors.Join(ErrInvalidModifier, fmt.Errorf("cannot apply modifier %s to statement %s", m, a))
-//	}
-//	a.Modifiers = append(a.Modifiers, m)
-//	return nil
-//}
-//
-//func (a *Axis) SetArgument(arg *types.Tuple) error {
-//	if !a.ValidArgument(arg.Type()) {
-//		return errors.Join(ErrInvalidArgument, fmt.Errorf("cannot apply argument %v to statement %s", arg, a))
-//	}
-//	values := arg.GetValues()
-//	a.Target = values[0].Value().(string) // inferred by ValidArgument
-//	for _, v := range values {
-//		switch v.Type() {
-//		case types.StringType:
-//			a.Label = v.Value().(string) // inferred by ValidArgument
-//		case types.NewListType(types.FloatType):
-//			vl := v.Value().([]float64) // inferred by ValidArgument
-//			if len(vl) != 2 {
-//				return errors.Join(ErrInvalidArgument, fmt.Errorf("invalid length for range %v", vl))
-//			}
-//			a.Range = [2]float64{vl[0], vl[1]}
-//		}
-//	}
-//	return nil
-//}
-//
-//func (a *Axis) ValidArgument(t types.Type) bool {
-//	valids := []types.Type{
-//		types.NewTupleType(types.DefaultLiteralType),
-//		types.NewTupleType(types.DefaultLiteralType, types.NewListType(types.FloatType)),
-//		types.NewTupleType(types.DefaultLiteralType, types.StringType),
-//		types.NewTupleType(types.DefaultLiteralType, types.NewListType(types.FloatType), types.StringType),
-//		types.NewTupleType(types.DefaultLiteralType, types.StringType, types.NewListType(types.FloatType)),
-//	}
-//	for _, v := range valids {
-//		if t.Is(v) {
-//			return true
-//		}
-//	}
-//	return false
-//}
-//
-//func (a *Axis) String() string {
-//	return fmt.Sprintf("Axis{%s %s [%f %f]}", a.Target, a.Label, a.Range[0], a.Range[1])
-//}
+func parseRange(l *types.List) ([2]float64, error) {
+	fs, err := toFloats(l)
+	if err != nil {
+		return [2]float64{}, err
+	}
+	if len(fs) != 2 {
+		return [2]float64{}, errors.Join(ErrInvalidArgument, fmt.Errorf("invalid length for range %v", fs))
+	}
+	if fs[0] == fs[1] {
+		return [2]float64{}, errors.Join(ErrInvalidArgument, fmt.Errorf("empty range %v", fs))
+	}
+	return [2]float64{fs[0], fs[1]}, nil
+}
@@ -0,0 +1,38 @@
+package types
+
+import "fmt"
+
+// TypeError is an error of a value of type Got where a value of type Want was expected. Err is the kind of the
+// error, like ErrInvalidList, matched with errors.Is.
+type TypeError struct {
+	Want    Type
+	Got     Type
+	Context string // what has the wrong type, e.g. "color component 2.5", may be empty
+	Err     error
+}
+
+func (e *TypeError) Error() string {
+	msg := fmt.Sprintf("expected %s, found %s", typeName(e.Want), typeName(e.Got))
+	if e.Context != "" {
+		msg = e.Context + ": " + msg
+	}
+	if e.Err == nil {
+		return msg
+	}
+	return e.Err.Error() + "\n" + msg
+}
+
+func (e *TypeError) Unwrap() error {
+	return e.Err
+}
+
+// typeName returns the name of t in messages, where the default literals are the names written without quotes.
+func typeName(t Type) string {
+	switch {
+	case t == nil:
+		return "nothing"
+	case t == DefaultLiteralType:
+		return "name"
+	}
+	return t.String()
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"testing"
+)
+
+// decode builds a value from the bytes of data: i, f, s and d are an int, a float, a string and a default literal,
+// ( and [ start a tuple and a list ended by ) and ].
+func decode(data []byte) (Value, []byte) {
+	if len(data) == 0 {
+		return Int(0), nil
+	}
+	c, data := data[0], data[1:]
+	switch c {
+	case 'f':
+		return Float(0.5), data
+	case 's':
+		return String("s"), data
+	case 'd':
+		return NewDefaultLiteral("d"), data
+	case '(', '[':
+		var container ValueContainer = new(Tuple)
+		end := byte(')')
+		if c == '[' {
+			container, end = new(List), ']'
+		}
+		for len(data) > 0 && data[0] != end {
+			var v Value
+			v, data = decode(data)
+			_ = container.AddValues(v) // the values of another type are skipped
+		}
+		if len(data) > 0 {
+			data = data[1:]
+		}
+		return container.(Value), data
+	}
+	return Int(1), data
+}
+
+// FuzzCast checks the properties of Type.Is, Type.Castable and Value.Cast documented in Type.
+func FuzzCast(f *testing.F) {
+	for _, seed := range [][2]string{
+		{"i", "f"}, {"i", "s"}, {"d", "s"}, {"(i)", "i"}, {"[ii]", "([ff])"}, {"[]", "[i]"}, {"[[]]", "[[s]]"},
+		{"(is)", "(if)"}, {"((i))", "i"}, {"[(is)(is)]", "[(is)]"}, {"[[][i][s]]", "[[s]]"}, {"[[][[]][[i]]]", "[[[s]]]"},
+	} {
+		f.Add([]byte(seed[0]), []byte(seed[1]))
+	}
+	f.Fuzz(func(t *testing.T, a, b []byte) {
+		va, _ := decode(a)
+		vb, _ := decode(b)
+		ta, tb := va.Type(), vb.Type()
+		_, _ = ta.String(), va.Value()
+		if ta.Is(tb) != tb.Is(ta) {
+			t.Errorf("Is is not symmetric for %s and %s", ta, tb)
+		}
+		if ta.Is(tb) && !ta.Castable(tb) {
+			t.Errorf("%s is %s but cannot be cast to it", ta, tb)
+		}
+		if res, ok := va.Cast(tb); ok {
+			if !ta.Castable(tb) {
+				t.Errorf("%s was cast to %s but is not castable to it", ta, tb)
+			}
+			if !res.Type().Is(tb) {
+				t.Errorf("%s was cast to %s, got %s", ta, tb, res.Type())
+			}
+		}
+	})
+}
@@ -43,11 +43,14 @@ func (t *LiteralType) Castable(target Type) bool {
 	case defaultLiteral:
 		return target.Is(StringType)
 	default:
-		panic("unhandled literal type")
+		return false
 	}
 }
 
 func (t *LiteralType) Is(other Type) bool {
+	if tuple, ok := other.(*TupleType); ok { // a tuple with a single value, like in TupleType.Is
+		return tuple.Is(t)
+	}
 	otherLiteral, ok := other.(*LiteralType)
 	return ok && t.t == otherLiteral.t
 }
@@ -117,6 +120,7 @@ func NewTupleType(types ...Type) *TupleType {
 	return &TupleType{types}
 }
 
+// ListType is the type of a list. The type of the values of an empty list is nil: it is a list of any type.
 type ListType struct {
 	t Type
 }
@@ -134,15 +138,63 @@ func (t *ListType) Castable(target Type) bool {
 }
 
 func (t *ListType) Is(other Type) bool {
+	if tuple, ok := other.(*TupleType); ok {
+		return tuple.Is(t)
+	}
 	otherList, ok := other.(*ListType)
 
-	return ok && t.t.Is(otherList.t)
+	return ok && (t.t == nil || otherList.t == nil || t.t.Is(otherList.t))
 }
 
 func (t *ListType) String() string {
+	if t.t == nil {
+		return "[]"
+	}
 	return "[" + t.t.String() + "]"
 }
 
 func NewListType(tpe Type) *ListType {
 	return &ListType{tpe}
 }
+
+// unify returns the most precise of the types t and other, when t.Is(other): the type of the values of the empty lists
+// of t is the one of the values of the matching lists of other, e.g. [int] for [] and [int].
+func unify(t, other Type) Type {
+	switch t := t.(type) {
+	case *ListType:
+		o, ok := other.(*ListType)
+		if !ok || o.t == nil {
+			return t
+		}
+		if t.t == nil {
+			return o
+		}
+		return NewListType(unify(t.t, o.t))
+	case *TupleType:
+		o, ok := other.(*TupleType)
+		if !ok || len(o.types) != len(t.types) {
+			return t
+		}
+		types := make([]Type, len(t.types))
+		for i := range t.types {
+			types[i] = unify(t.types[i], o.types[i])
+		}
+		return NewTupleType(types...)
+	}
+	return t
+}
+
+// complete returns true if t has no empty list type, so that unify(t, other) is t.
+func complete(t Type) bool {
+	switch t := t.(type) {
+	case *ListType:
+		return t.t != nil && complete(t.t)
+	case *TupleType:
+		for _, e := range t.types {
+			if !complete(e) {
+				return false
+			}
+		}
+	}
+	return true
+}
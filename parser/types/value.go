@@ -1,7 +1,8 @@
 package types
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
 	"strconv"
 )
 
@@ -11,8 +12,10 @@ type Value interface {
 	Value() any
 }
 
+var ErrInvalidList = errors.New("invalid list")
+
 type ValueContainer interface {
-	AddValues(...Value)
+	AddValues(...Value) error
 	GetValues() []Value
 	CanContain(Value) bool
 }
@@ -28,6 +31,10 @@ func (t *Tuple) Type() Type {
 }
 
 func (t *Tuple) Cast(target Type) (Value, bool) {
+	if _, ok := target.(*TupleType); !ok && len(t.GetValues()) == 1 { // the value of a tuple with a single value
+		return t.GetValues()[0].Cast(target)
+	}
+
 	if target.Is(t.Type()) {
 		return t, true
 	}
@@ -37,10 +44,6 @@ func (t *Tuple) Cast(target Type) (Value, bool) {
 		return &res, true
 	}
 
-	if len(t.GetValues()) == 1 {
-		return t.GetValues()[0].Cast(target)
-	}
-
 	return nil, false
 }
 
@@ -48,8 +51,9 @@ func (t *Tuple) Value() any {
 	return t.GetValues()
 }
 
-func (t *Tuple) AddValues(v ...Value) {
+func (t *Tuple) AddValues(v ...Value) error {
 	*t = append(*t, v...)
+	return nil
 }
 
 func (t *Tuple) GetValues() []Value {
@@ -60,10 +64,51 @@ func (t *Tuple) CanContain(Value) bool {
 	return true
 }
 
-type List []Value
+// Unwrap returns the value of v if it is a tuple with a single value, like optional parentheses, and v otherwise.
+func Unwrap(v Value) Value {
+	for {
+		t, ok := v.(*Tuple)
+		if !ok || len(*t) != 1 {
+			return v
+		}
+		v = (*t)[0]
+	}
+}
 
+// List is a list of values of the same type, except for the types of the values of their empty lists, e.g. in
+// [[] [1]]. The zero value is an empty list of any type.
+type List struct {
+	values []Value
+	elem   Type // the type of values[:n], see elemType
+	n      int
+}
+
+// NewList returns the list of the values, or a *TypeError matching ErrInvalidList if they have different types.
+func NewList(values ...Value) (*List, error) {
+	l := new(List)
+	if err := l.AddValues(values...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Type returns the type of the list. The empty list is a list of any type.
 func (l *List) Type() Type {
-	return NewListType((*l)[0].Type()) // if the list is empty, the program crashes
+	return NewListType(l.elemType())
+}
+
+// elemType returns the most precise type of the values of the list, nil if it is empty: [int] for [[] [1]]. It is
+// computed once for each value.
+func (l *List) elemType() Type {
+	for ; l.n < len(l.values); l.n++ {
+		t := l.values[l.n].Type()
+		if l.elem == nil {
+			l.elem = t
+		} else if !complete(l.elem) {
+			l.elem = unify(l.elem, t)
+		}
+	}
+	return l.elem
 }
 
 func (l *List) Cast(target Type) (Value, bool) {
@@ -82,28 +127,36 @@ func (l *List) Value() any {
 	return l.GetValues()
 }
 
-func (l *List) AddValues(v ...Value) {
+// MarshalJSON marshals the list as its values, since its fields are unexported.
+func (l *List) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.values)
+}
+
+func (l *List) AddValues(v ...Value) error {
 	for _, value := range v {
-		if len(*l) == 0 {
-			*l = append(*l, value)
-		} else {
-			if !value.Type().Is((*l)[0].Type()) {
-				panic(fmt.Errorf("invalid list: cannot create a non homogene list"))
-			}
-			*l = append(*l, value)
+		if !l.CanContain(value) {
+			return &TypeError{Want: l.elemType(), Got: value.Type(), Err: ErrInvalidList}
 		}
+		l.values = append(l.values, value)
 	}
+	return nil
+}
+
+// Append adds values to the list without checking their types, for the values whose type is unknown like the
+// variables of a document parsed for its syntax.
+func (l *List) Append(v ...Value) {
+	l.values = append(l.values, v...)
 }
 
 func (l *List) GetValues() []Value {
-	return *l
+	return l.values
 }
 
 func (l *List) CanContain(v Value) bool {
-	if len(*l) == 0 {
+	if len(l.values) == 0 {
 		return true
 	}
-	return v.Type().Is((*l)[0].Type())
+	return v.Type().Is(l.elemType())
 }
 
 type Literal struct {
@@ -144,6 +197,11 @@ func (v Literal) Value() any {
 	return v.string
 }
 
+// MarshalJSON marshals the literal as its text, since its fields are unexported.
+func (v Literal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.string)
+}
+
 type String string
 
 func (v String) Type() Type {
@@ -155,7 +213,7 @@ func (v String) Cast(target Type) (Value, bool) {
 		return v, true
 	}
 
-	if target.Is(NewTupleType(StringType)) {
+	if target.Is(NewTupleType(v.Type())) {
 		t := (Tuple)([]Value{v})
 		return &t, true
 	}
@@ -178,7 +236,7 @@ func (v Int) Cast(target Type) (Value, bool) {
 		return v, true
 	}
 
-	if target.Is(NewTupleType(StringType)) {
+	if target.Is(NewTupleType(v.Type())) {
 		t := (Tuple)([]Value{v})
 		return &t, true
 	}
@@ -209,7 +267,7 @@ func (v Float) Cast(target Type) (Value, bool) {
 		return v, true
 	}
 
-	if target.Is(NewTupleType(StringType)) {
+	if target.Is(NewTupleType(v.Type())) {
 		t := (Tuple)([]Value{v})
 		return &t, true
 	}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 )
 
@@ -112,3 +114,76 @@ func TestList(t *testing.T) {
 		t.Error("List must contain only values with the same type")
 	}
 }
+
+func TestLiteralJSON(t *testing.T) {
+	b, err := json.Marshal(&Tuple{NewDefaultLiteral("red"), String("a"), Int(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `["red","a",1]` {
+		t.Error(`Expected ["red","a",1], got`, string(b))
+	}
+}
+
+func TestListError(t *testing.T) {
+	list := new(List)
+	if !list.Type().Is(NewListType(IntType)) || list.Type().String() != "[]" {
+		t.Error("Expected the empty list to be a list of any type, got", list.Type())
+	}
+	if err := list.AddValues(Int(1), Int(2)); err != nil {
+		t.Fatal(err)
+	}
+	err := list.AddValues(String("a"))
+	if !errors.Is(err, ErrInvalidList) {
+		t.Error("Expected", ErrInvalidList, "got", err)
+	}
+	var te *TypeError
+	if !errors.As(err, &te) || te.Want != IntType || te.Got != StringType || err.Error() != "invalid list\nexpected int, found string" {
+		t.Error("Expected a type error, got", err)
+	}
+	if len(list.GetValues()) != 2 {
+		t.Error("Expected 2 values, got", len(list.GetValues()))
+	}
+}
+
+func TestListEmptyValues(t *testing.T) {
+	ints, err := NewList(Int(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	strings, err := NewList(String("s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := NewList(new(List), ints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Type().String() != "[[int]]" {
+		t.Error("Expected [[int]], got", list.Type())
+	}
+	if list.CanContain(strings) {
+		t.Error("Expected [[] [1]] not to contain [\"s\"]")
+	}
+	if err = list.AddValues(strings); !errors.Is(err, ErrInvalidList) {
+		t.Error("Expected", ErrInvalidList, "got", err)
+	}
+	if !list.CanContain(new(List)) {
+		t.Error("Expected [[] [1]] to contain []")
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	inner := Tuple{String("a")}
+	outer := Tuple{&inner}
+	if v := Unwrap(&outer); v != String("a") {
+		t.Error("Expected a, got", v)
+	}
+	pair := Tuple{Int(1), Int(2)}
+	if v := Unwrap(&pair); v != &pair {
+		t.Error("Expected the tuple of two values, got", v)
+	}
+	if v, ok := outer.Cast(StringType); !ok || v.Value() != "a" {
+		t.Error("Expected the tuple cast to its value, got", v)
+	}
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+)
+
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// Variable is a use of a variable, e.g. $data. It behaves as the value the variable had when it was used.
+type Variable struct {
+	Name     string
+	Pos      lexer.Position
+	Resolved types.Value
+}
+
+func (v *Variable) Type() types.Type {
+	return v.Resolved.Type()
+}
+
+func (v *Variable) Cast(target types.Type) (types.Value, bool) {
+	return v.Resolved.Cast(target)
+}
+
+func (v *Variable) Value() any {
+	return v.Resolved.Value()
+}
+
+// variables holds the variables defined while parsing a document, by their name.
+type variables map[string]types.Value
+
+// define records the variable defined by a let statement: let name value.
+func (vars variables) define(stmt *Statement) error {
+	if len(stmt.Modifiers) > 0 {
+		return errors.Join(ErrInvalidModifier, fmt.Errorf("%s does not accept modifiers", stmt))
+	}
+	name, value, err := LetDefinition(stmt)
+	if err != nil {
+		return err
+	}
+	vars[name] = value
+	return nil
+}
+
+// LetDefinition returns the name and the value of the variable defined by stmt, a let statement.
+// Several values are defined as a tuple.
+func LetDefinition(stmt *Statement) (string, types.Value, error) {
+	var values []types.Value
+	if stmt.Arguments != nil {
+		values = stmt.Arguments.GetValues()
+	}
+	if len(values) < 2 {
+		return "", nil, errors.Join(ErrInvalidArgument, fmt.Errorf("%s requires a name and a value", stmt))
+	}
+	lit, ok := values[0].(types.Literal) // neither a variable nor a tuple
+	if !ok || !lit.Type().Is(types.DefaultLiteralType) {
+		ctx := fmt.Sprintf("the variable of %s", stmt)
+		return "", nil, &types.TypeError{Want: types.DefaultLiteralType, Got: values[0].Type(), Context: ctx, Err: ErrInvalidArgument}
+	}
+	name := lit.Value().(string)
+	if len(values) == 2 {
+		return name, values[1], nil
+	}
+	t := types.Tuple(values[1:])
+	return name, &t, nil
+}
+
+// resolve returns v with its variables replaced by their value.
+func resolve(v types.Value) types.Value {
+	switch v := v.(type) {
+	case *Variable:
+		return resolve(v.Resolved)
+	case *types.Tuple:
+		res := make(types.Tuple, len(*v))
+		for i, x := range *v {
+			res[i] = resolve(x)
+		}
+		return &res
+	case *types.List:
+		res := new(types.List)
+		for _, x := range v.GetValues() {
+			res.Append(resolve(x)) // of the type of x, checked when parsing
+		}
+		return res
+	}
+	return v
+}
+
+// resolveArguments returns args with its variables replaced by their value. Like parenthesis, a variable which is
+// the only argument and whose value is a tuple gives its values as arguments.
+func resolveArguments(args *types.Tuple) *types.Tuple {
+	if args == nil {
+		return nil
+	}
+	values := args.GetValues()
+	if len(values) == 1 {
+		if _, ok := values[0].(*Variable); ok {
+			if t, ok := resolve(values[0]).(*types.Tuple); ok {
+				return t
+			}
+		}
+	}
+	return resolve(args).(*types.Tuple)
+}
+
+// resolveModifiers returns mods with the variables of their arguments replaced by their value.
+func resolveModifiers(mods []*Modifier) []*Modifier {
+	res := make([]*Modifier, len(mods))
+	for i, m := range mods {
+		res[i] = &Modifier{Name: m.Name, Arguments: resolveArguments(m.Arguments), Pos: m.Pos}
+	}
+	return res
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"github.com/planklang/goplank/lexer"
+	"github.com/planklang/goplank/parser/types"
+	"testing"
+)
+
+func TestVariable(t *testing.T) {
+	tree, err := evalString(t, "let xs [1 2 3]\nlet c 0 0 255\nlet w 2.5\nplot $xs [1 4 9] | color $c | width $w\n---\nlet xs [4 5 6]\nplot $xs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := tree.Body[0].Plots[0]
+	if p.X[2] != 3 || p.Color.B != 255 || p.Width != 2.5 {
+		t.Error("Expected the values of the variables, got", p.X, p.Color, p.Width)
+	}
+	if y := tree.Body[1].Plots[0].Y; y[0] != 4 {
+		t.Error("Expected the redefined variable, got", y)
+	}
+	if v, ok := tree.Body[0].Stmts[3].Arguments.GetValues()[0].(*Variable); !ok || v.Name != "xs" || v.Pos.Column != 5 {
+		t.Error("Expected the use of xs to stay in the tree, got", tree.Body[0].Stmts[3].Arguments.GetValues()[0])
+	}
+
+	tree, err = evalString(t, "let n 2\nplot [1 $n 3]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y := tree.Body[0].Plots[0].Y; y[1] != 2 {
+		t.Error("Expected a variable in a list, got", y)
+	}
+}
+
+func TestVariableError(t *testing.T) {
+	for src, expected := range map[string]error{
+		"plot $xs":               ErrUndefinedVariable,
+		"plot $xs\nlet xs [1 2]": ErrUndefinedVariable,
+		"let xs":                 ErrInvalidArgument,
+		"let 1 [1 2]":            ErrInvalidArgument,
+		"let xs [1] | color red": ErrInvalidModifier,
+	} {
+		lex, err := lexer.Lex(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = Parse(lex); !errors.Is(err, expected) {
+			t.Error("Expected", expected, "for", src, "got", err)
+		}
+	}
+}
+
+func TestConfigVariables(t *testing.T) {
+	lex, err := lexer.Lex("plot $xs\nlet xs [4 5]\nplot $xs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xs, err := types.NewList(types.Int(1), types.Int(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := (&Config{Variables: map[string]types.Value{"xs": xs}}).Parse(context.Background(), lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tree.Eval(); err != nil {
+		t.Fatal(err)
+	}
+	if p := tree.Body[0].Plots; p[0].Y[1] != 2 || p[1].Y[1] != 5 {
+		t.Error("Expected the predefined variable, then the let statement, got", p[0].Y, p[1].Y)
+	}
+}
@@ -0,0 +1,13 @@
+// Package backends registers every backend shipped with goplank:
+//
+//	import _ "github.com/planklang/goplank/render/backends"
+package backends
+
+import (
+	_ "github.com/planklang/goplank/render/gnuplot"
+	_ "github.com/planklang/goplank/render/matplotlib"
+	_ "github.com/planklang/goplank/render/pgfplots"
+	_ "github.com/planklang/goplank/render/svg"
+	_ "github.com/planklang/goplank/render/text"
+	_ "github.com/planklang/goplank/render/vegalite"
+)
@@ -0,0 +1,60 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/planklang/goplank/internal/planktest"
+	"github.com/planklang/goplank/render"
+	"testing"
+)
+
+func TestBackends(t *testing.T) {
+	for file, expected := range map[string]string{
+		"figure.vl.json": "vegalite",
+		"figure.gp":      "gnuplot",
+		"figure.py":      "matplotlib",
+		"figure.tex":     "pgfplots",
+		"figure.svg":     "svg",
+		"figure.txt":     "text",
+	} {
+		name, err := render.ForFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, file, name)
+		}
+		if _, err = render.Lookup(name); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// countdown is a context done after n calls to its Err method.
+type countdown struct {
+	context.Context
+	n int
+}
+
+func (c *countdown) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestBackendsCancel(t *testing.T) {
+	tree := planktest.Eval(t, "plot [1 2]\n---\nplot [3 4]\n---\nplot [5 6]")
+	for _, name := range render.Backends() {
+		r, err := render.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		ctx := &countdown{context.Background(), 1}
+		if _, err = r.Render(ctx, &buf, tree); !errors.Is(err, context.Canceled) || buf.Len() != 0 {
+			t.Error("Expected", name, "to stop in the second figure, got", err, buf.Len())
+		}
+	}
+}
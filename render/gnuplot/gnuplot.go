@@ -0,0 +1,181 @@
+package gnuplot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	dashTypes = map[parser.Dash]int{
+		parser.DashSolid:  1,
+		parser.DashDashed: 2,
+		parser.DashDotted: 3,
+	}
+	pointTypes = map[parser.Marker]int{
+		parser.MarkerCircle:   7,
+		parser.MarkerSquare:   5,
+		parser.MarkerTriangle: 9,
+		parser.MarkerDiamond:  13,
+		parser.MarkerPlus:     1,
+	}
+)
+
+func init() {
+	render.Register("gnuplot", render.RendererFunc(Render), ".gp", ".gnuplot", ".plt")
+}
+
+// Render writes a gnuplot script drawing an evaluated document to w.
+// The data of every plot is written in an inline data block, so the script needs gnuplot 5 or later.
+// Several figures are drawn in a multiplot, one below the other.
+func Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]render.Warning, error) {
+	var warns []render.Warning
+	var buf bytes.Buffer
+
+	buf.WriteString("# generated by goplank\n")
+	buf.WriteString("# choose the output with e.g.: set terminal svg; set output 'figure.svg'\n")
+	buf.WriteString("set termoption noenhanced\n\n")
+
+	for i, f := range a.Body {
+		if err := ctx.Err(); err != nil {
+			return warns, err
+		}
+		for j, p := range f.Plots {
+			fmt.Fprintf(&buf, "%s << EOD\n", blockName(i, j))
+			for k := range p.X {
+				fmt.Fprintf(&buf, "%s %s\n", number(p.X[k]), number(p.Y[k]))
+			}
+			buf.WriteString("EOD\n")
+		}
+	}
+	buf.WriteString("\n")
+
+	if len(a.Body) > 1 {
+		fmt.Fprintf(&buf, "set multiplot layout %d,1\n\n", len(a.Body))
+	}
+	for i, f := range a.Body {
+		if err := ctx.Err(); err != nil {
+			return warns, err
+		}
+		warns = append(warns, writeFigure(&buf, i, f)...)
+	}
+	if len(a.Body) > 1 {
+		buf.WriteString("unset multiplot\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return warns, err
+}
+
+func writeFigure(buf *bytes.Buffer, n int, f *parser.Figure) []render.Warning {
+	var warns []render.Warning
+
+	fmt.Fprintf(buf, "# figure %d\n", n+1)
+	buf.WriteString("unset logscale\nunset grid\n")
+	for _, target := range []string{"x", "y"} {
+		a := f.Axis(target)
+		if a == nil {
+			a = &parser.Axis{Target: target}
+		}
+		if a.Label != "" {
+			fmt.Fprintf(buf, "set %slabel %s\n", target, quote(a.Label))
+		} else {
+			fmt.Fprintf(buf, "unset %slabel\n", target)
+		}
+		if a.HasRange() {
+			fmt.Fprintf(buf, "set %srange [%s:%s]\n", target, number(a.Range[0]), number(a.Range[1]))
+		} else {
+			fmt.Fprintf(buf, "set %srange [*:*]\n", target)
+		}
+		if a.Scale == parser.ScaleLog {
+			fmt.Fprintf(buf, "set logscale %s\n", target)
+		}
+		if a.Grid {
+			fmt.Fprintf(buf, "set grid %stics\n", target)
+		}
+		if render.NonPositive(f, target) {
+			warns = append(warns, render.Warning{Figure: n, Message: fmt.Sprintf("gnuplot ignores non-positive values on the log scale of axis %s", target)})
+		}
+	}
+
+	if len(f.Plots) == 0 {
+		warns = append(warns, render.Warning{Figure: n, Message: "figure has no plot"})
+		buf.WriteString("unset key\nplot NaN notitle\n\n") // draws the axes only
+		return warns
+	}
+
+	labelled := false
+	for _, p := range f.Plots {
+		labelled = labelled || p.Label != ""
+	}
+	if labelled {
+		buf.WriteString("set key\n")
+	} else {
+		buf.WriteString("unset key\n")
+	}
+
+	var plots []string
+	for j, p := range f.Plots {
+		plots = append(plots, plotCommand(blockName(n, j), p, j))
+	}
+	fmt.Fprintf(buf, "plot %s\n\n", strings.Join(plots, ", \\\n     "))
+	return warns
+}
+
+func plotCommand(block string, p *parser.Plot, i int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s using 1:2", block)
+	switch p.Style {
+	case parser.StyleScatter:
+		pt, ok := pointTypes[p.Marker]
+		if !ok {
+			pt = pointTypes[parser.MarkerCircle]
+		}
+		fmt.Fprintf(&b, " with points pt %d", pt)
+	case parser.StyleBar:
+		b.WriteString(" with boxes fs solid")
+	default:
+		if pt, ok := pointTypes[p.Marker]; ok {
+			fmt.Fprintf(&b, " with linespoints pt %d", pt)
+		} else {
+			b.WriteString(" with lines")
+		}
+		fmt.Fprintf(&b, " dt %d", dashTypes[p.Dash])
+	}
+
+	c := render.PlotColor(p, i)
+	// gnuplot reads #AARRGGBB with AA as the transparency
+	if c.A == 255 {
+		fmt.Fprintf(&b, " lc rgb %q", render.Hex(c))
+	} else {
+		fmt.Fprintf(&b, " lc rgb \"#%02x%02x%02x%02x\"", 255-c.A, c.R, c.G, c.B)
+	}
+	if p.Width != 0 {
+		fmt.Fprintf(&b, " lw %s", number(p.Width))
+	}
+	if p.Label != "" {
+		fmt.Fprintf(&b, " title %s", quote(p.Label))
+	} else {
+		b.WriteString(" notitle")
+	}
+	return b.String()
+}
+
+func blockName(figure, plot int) string {
+	return fmt.Sprintf("$figure%d_plot%d", figure+1, plot+1)
+}
+
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// quote returns s as a double-quoted gnuplot string.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,50 @@
+package gnuplot
+
+import (
+	"bytes"
+	"context"
+	"github.com/planklang/goplank/internal/planktest"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	warns, err := Render(context.Background(), &buf, planktest.Eval(t, "axis x 'Time \"s\"' [0 10]\naxis y | scale log | grid\nplot [1 2] [3 4] 'a' | color red | width 2 | dash dashed\nplot [1 2] | style scatter | marker square"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 0 {
+		t.Error("Expected no warnings, got", warns)
+	}
+	script := buf.String()
+	for _, s := range []string{
+		"$figure1_plot1 << EOD\n1 3\n2 4\nEOD\n",
+		"$figure1_plot2 << EOD\n0 1\n1 2\nEOD\n",
+		`set xlabel "Time \"s\""`,
+		"set xrange [0:10]",
+		"set logscale y",
+		"set grid ytics",
+		`$figure1_plot1 using 1:2 with lines dt 2 lc rgb "#ff0000" lw 2 title "a"`,
+		"$figure1_plot2 using 1:2 with points pt 5",
+	} {
+		if !strings.Contains(script, s) {
+			t.Errorf("Expected %q in\n%s", s, script)
+		}
+	}
+	if strings.Contains(script, "multiplot") {
+		t.Error("Expected a single plot, got", script)
+	}
+
+	buf.Reset()
+	if _, err = Render(context.Background(), &buf, planktest.Eval(t, "plot [1]\n---\nplot [2] | color (0 0 255 0.5)")); err != nil {
+		t.Fatal(err)
+	}
+	script = buf.String()
+	if !strings.Contains(script, "set multiplot layout 2,1") {
+		t.Error("Expected a multiplot, got", script)
+	}
+	if !strings.Contains(script, `lc rgb "#7f0000ff"`) {
+		t.Error("Expected a transparent color, got", script)
+	}
+}
@@ -0,0 +1,152 @@
+package matplotlib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	lineStyles = map[parser.Dash]string{
+		parser.DashSolid:  "-",
+		parser.DashDashed: "--",
+		parser.DashDotted: ":",
+	}
+	markers = map[parser.Marker]string{
+		parser.MarkerCircle:   "o",
+		parser.MarkerSquare:   "s",
+		parser.MarkerTriangle: "^",
+		parser.MarkerDiamond:  "D",
+		parser.MarkerPlus:     "+",
+	}
+)
+
+func init() {
+	render.Register("matplotlib", render.RendererFunc(Render), ".py")
+}
+
+// Render writes a standalone Python script drawing an evaluated document with matplotlib to w.
+// Each figure of the document is a subplot, one below the other.
+func Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]render.Warning, error) {
+	var warns []render.Warning
+	var buf bytes.Buffer
+
+	buf.WriteString("# generated by goplank\n")
+	buf.WriteString("import matplotlib.pyplot as plt\n\n")
+	n := max(len(a.Body), 1)
+	fmt.Fprintf(&buf, "fig, axes = plt.subplots(%d, 1, squeeze=False, figsize=(6.4, %s))\n", n, number(4.8*float64(n)))
+
+	for i, f := range a.Body {
+		if err := ctx.Err(); err != nil {
+			return warns, err
+		}
+		warns = append(warns, writeFigure(&buf, i, f)...)
+	}
+
+	buf.WriteString("\nfig.tight_layout()\n")
+	buf.WriteString("plt.show()\n")
+
+	_, err := w.Write(buf.Bytes())
+	return warns, err
+}
+
+func writeFigure(buf *bytes.Buffer, n int, f *parser.Figure) []render.Warning {
+	var warns []render.Warning
+
+	fmt.Fprintf(buf, "\n# figure %d\n", n+1)
+	fmt.Fprintf(buf, "ax = axes[%d, 0]\n", n)
+
+	labelled := false
+	for i, p := range f.Plots {
+		labelled = labelled || p.Label != ""
+		fmt.Fprintf(buf, "x = %s\n", list(p.X))
+		fmt.Fprintf(buf, "y = %s\n", list(p.Y))
+		fmt.Fprintf(buf, "%s\n", plotCall(p, i))
+	}
+	if len(f.Plots) == 0 {
+		warns = append(warns, render.Warning{Figure: n, Message: "figure has no plot"})
+	}
+
+	for _, target := range []string{"x", "y"} {
+		a := f.Axis(target)
+		if a == nil {
+			continue
+		}
+		if a.Label != "" {
+			fmt.Fprintf(buf, "ax.set_%slabel(%s)\n", target, strconv.Quote(a.Label))
+		}
+		if a.Scale == parser.ScaleLog {
+			fmt.Fprintf(buf, "ax.set_%sscale(\"log\")\n", target)
+		}
+		if a.HasRange() {
+			fmt.Fprintf(buf, "ax.set_%slim(%s, %s)\n", target, number(a.Range[0]), number(a.Range[1]))
+		}
+		if a.Grid {
+			fmt.Fprintf(buf, "ax.grid(True, axis=\"%s\")\n", target)
+		}
+		if render.NonPositive(f, target) {
+			warns = append(warns, render.Warning{Figure: n, Message: fmt.Sprintf("matplotlib masks non-positive values on the log scale of axis %s", target)})
+		}
+	}
+	if labelled {
+		buf.WriteString("ax.legend()\n")
+	}
+	return warns
+}
+
+func plotCall(p *parser.Plot, i int) string {
+	c := render.PlotColor(p, i)
+	args := []string{"x", "y", "color=" + strconv.Quote(render.Hex(c))}
+	if c.A != 255 {
+		args = append(args, "alpha="+strconv.FormatFloat(render.Opacity(c), 'g', 3, 64))
+	}
+
+	var fn string
+	switch p.Style {
+	case parser.StyleScatter:
+		fn = "scatter"
+		m, ok := markers[p.Marker]
+		if !ok {
+			m = markers[parser.MarkerCircle]
+		}
+		args = append(args, "marker="+strconv.Quote(m))
+		if p.Width != 0 {
+			args = append(args, "linewidths="+number(p.Width))
+		}
+	case parser.StyleBar:
+		fn = "bar"
+		if p.Width != 0 {
+			args = append(args, "linewidth="+number(p.Width))
+		}
+	default:
+		fn = "plot"
+		args = append(args, "linestyle="+strconv.Quote(lineStyles[p.Dash]))
+		if m, ok := markers[p.Marker]; ok {
+			args = append(args, "marker="+strconv.Quote(m))
+		}
+		if p.Width != 0 {
+			args = append(args, "linewidth="+number(p.Width))
+		}
+	}
+	if p.Label != "" {
+		args = append(args, "label="+strconv.Quote(p.Label))
+	}
+	return fmt.Sprintf("ax.%s(%s)", fn, strings.Join(args, ", "))
+}
+
+func list(fs []float64) string {
+	s := make([]string, len(fs))
+	for i, f := range fs {
+		s[i] = number(f)
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,40 @@
+package matplotlib
+
+import (
+	"bytes"
+	"context"
+	"github.com/planklang/goplank/internal/planktest"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	warns, err := Render(context.Background(), &buf, planktest.Eval(t, "axis x 'Time' [0 10]\naxis y | scale log | grid\nplot [1 2] [3 4] 'a' | color (255 0 0 0.5) | width 2 | dash dotted | marker diamond\nplot [1 2] | style bar\n---\nplot [5 6] | style scatter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 0 {
+		t.Error("Expected no warnings, got", warns)
+	}
+	script := buf.String()
+	for _, s := range []string{
+		"fig, axes = plt.subplots(2, 1, squeeze=False, figsize=(6.4, 9.6))",
+		"ax = axes[0, 0]",
+		"x = [1, 2]\ny = [3, 4]\n",
+		`ax.plot(x, y, color="#ff0000", alpha=0.502, linestyle=":", marker="D", linewidth=2, label="a")`,
+		`ax.bar(x, y, color="#ff7f0e")`,
+		`ax.set_xlabel("Time")`,
+		"ax.set_xlim(0, 10)",
+		`ax.set_yscale("log")`,
+		`ax.grid(True, axis="y")`,
+		"ax.legend()",
+		"ax = axes[1, 0]",
+		`ax.scatter(x, y, color="#1f77b4", marker="o")`,
+		"plt.show()",
+	} {
+		if !strings.Contains(script, s) {
+			t.Errorf("Expected %q in\n%s", s, script)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package pgfplots
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// tableLength is the number of points from which the data of a plot is written as a table instead of coordinates.
+// Long series are easier to read and to edit one point per line.
+const tableLength = 16
+
+var (
+	dashes = map[parser.Dash]string{
+		parser.DashSolid:  "solid",
+		parser.DashDashed: "dashed",
+		parser.DashDotted: "dotted",
+	}
+	marks = map[parser.Marker]string{
+		parser.MarkerCircle:   "*",
+		parser.MarkerSquare:   "square*",
+		parser.MarkerTriangle: "triangle*",
+		parser.MarkerDiamond:  "diamond*",
+		parser.MarkerPlus:     "+",
+	}
+)
+
+func init() {
+	render.Register("pgfplots", render.RendererFunc(Render), ".tikz", ".pgf", ".tex")
+}
+
+// Render writes a tikzpicture with a pgfplots axis environment for each figure of an evaluated document to w.
+// The document including the output needs \usepackage{pgfplots}.
+func Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]render.Warning, error) {
+	var warns []render.Warning
+	var buf bytes.Buffer
+
+	buf.WriteString("% generated by goplank, requires \\usepackage{pgfplots}\n")
+	for i, f := range a.Body {
+		if err := ctx.Err(); err != nil {
+			return warns, err
+		}
+		buf.WriteString("\n")
+		warns = append(warns, writeFigure(&buf, i, f)...)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return warns, err
+}
+
+func writeFigure(buf *bytes.Buffer, n int, f *parser.Figure) []render.Warning {
+	var warns []render.Warning
+
+	fmt.Fprintf(buf, "%% figure %d\n", n+1)
+	buf.WriteString("\\begin{tikzpicture}\n")
+	for i, p := range f.Plots {
+		c := render.PlotColor(p, i)
+		fmt.Fprintf(buf, "\\definecolor{plank%d}{RGB}{%d,%d,%d}\n", i+1, c.R, c.G, c.B)
+	}
+
+	var options []string
+	for _, target := range []string{"x", "y"} {
+		a := f.Axis(target)
+		if a == nil {
+			continue
+		}
+		if a.Label != "" {
+			options = append(options, fmt.Sprintf("%slabel={%s}", target, escape(a.Label)))
+		}
+		if a.HasRange() {
+			lo, hi := a.Range[0], a.Range[1]
+			if lo > hi { // pgfplots wants min < max
+				lo, hi = hi, lo
+				options = append(options, target+" dir=reverse")
+			}
+			options = append(options, fmt.Sprintf("%smin=%s, %smax=%s", target, number(lo), target, number(hi)))
+		}
+		if a.Scale == parser.ScaleLog {
+			options = append(options, target+"mode=log")
+		}
+		if a.Grid {
+			options = append(options, target+"majorgrids")
+		}
+		if render.NonPositive(f, target) {
+			warns = append(warns, render.Warning{Figure: n, Message: fmt.Sprintf("pgfplots discards non-positive values on the log scale of axis %s", target)})
+		}
+	}
+	if len(options) == 0 {
+		buf.WriteString("\\begin{axis}\n")
+	} else {
+		fmt.Fprintf(buf, "\\begin{axis}[\n  %s,\n]\n", strings.Join(options, ",\n  "))
+	}
+
+	if len(f.Plots) == 0 {
+		warns = append(warns, render.Warning{Figure: n, Message: "figure has no plot"})
+	}
+	for i, p := range f.Plots {
+		writePlot(buf, p, i)
+	}
+
+	buf.WriteString("\\end{axis}\n")
+	buf.WriteString("\\end{tikzpicture}\n")
+	return warns
+}
+
+func writePlot(buf *bytes.Buffer, p *parser.Plot, i int) {
+	options := []string{fmt.Sprintf("color=plank%d", i+1)}
+	switch p.Style {
+	case parser.StyleScatter:
+		m, ok := marks[p.Marker]
+		if !ok {
+			m = marks[parser.MarkerCircle]
+		}
+		options = append(options, "only marks", "mark="+m)
+	case parser.StyleBar:
+		options = append(options, "ybar", fmt.Sprintf("fill=plank%d", i+1))
+	default:
+		options = append(options, dashes[p.Dash])
+		if m, ok := marks[p.Marker]; ok {
+			options = append(options, "mark="+m, "mark options={solid}")
+		} else {
+			options = append(options, "mark=none")
+		}
+	}
+	c := render.PlotColor(p, i)
+	if c.A != 255 {
+		options = append(options, "opacity="+strconv.FormatFloat(render.Opacity(c), 'g', 3, 64))
+	}
+	if p.Width != 0 {
+		options = append(options, fmt.Sprintf("line width=%spt", number(p.Width)))
+	}
+	if p.Label == "" {
+		options = append(options, "forget plot") // keeps the legend entries aligned with the labelled plots
+	}
+
+	fmt.Fprintf(buf, "\\addplot[%s]", strings.Join(options, ", "))
+	if len(p.X) < tableLength {
+		buf.WriteString(" coordinates {")
+		for j := range p.X {
+			fmt.Fprintf(buf, " (%s,%s)", number(p.X[j]), number(p.Y[j]))
+		}
+		buf.WriteString(" };\n")
+	} else {
+		buf.WriteString(" table {\n  x y\n")
+		for j := range p.X {
+			fmt.Fprintf(buf, "  %s %s\n", number(p.X[j]), number(p.Y[j]))
+		}
+		buf.WriteString("};\n")
+	}
+	if p.Label != "" {
+		fmt.Fprintf(buf, "\\addlegendentry{%s}\n", escape(p.Label))
+	}
+}
+
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`^`, `\textasciicircum{}`,
+	`_`, `\_`,
+	`%`, `\%`,
+	`~`, `\textasciitilde{}`,
+)
+
+// escape escapes the LaTeX special characters of s, except inside $...$ so labels may contain math.
+func escape(s string) string {
+	parts := strings.Split(s, "$")
+	if len(parts)%2 == 0 { // unbalanced $
+		return escaper.Replace(s)
+	}
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = escaper.Replace(parts[i])
+	}
+	return strings.Join(parts, "$")
+}
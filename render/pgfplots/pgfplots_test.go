@@ -0,0 +1,50 @@
+package pgfplots
+
+import (
+	"bytes"
+	"context"
+	"github.com/planklang/goplank/internal/planktest"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	warns, err := Render(context.Background(), &buf, planktest.Eval(t, "axis x 'Time_s $t_0$' [10 0]\naxis y | scale log | grid\nplot [1 2] [3 4] 'a' | color red | width 2 | dash dashed | marker square\nplot [1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16] | style scatter\n---\nplot [1]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 0 {
+		t.Error("Expected no warnings, got", warns)
+	}
+	out := buf.String()
+	for _, s := range []string{
+		"\\definecolor{plank1}{RGB}{255,0,0}",
+		"xlabel={Time\\_s $t_0$}",
+		"x dir=reverse",
+		"xmin=0, xmax=10",
+		"ymode=log",
+		"ymajorgrids",
+		"\\addplot[color=plank1, dashed, mark=square*, mark options={solid}, line width=2pt] coordinates { (1,3) (2,4) };\n\\addlegendentry{a}\n",
+		"\\addplot[color=plank2, only marks, mark=*, forget plot] table {\n  x y\n  0 1\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in\n%s", s, out)
+		}
+	}
+	if strings.Count(out, "\\begin{tikzpicture}") != 2 || strings.Count(out, "\\end{axis}") != 2 {
+		t.Error("Expected 2 tikzpictures, got", out)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for s, expected := range map[string]string{
+		"a_b":         `a\_b`,
+		"50% & $x^2$": `50\% \& $x^2$`,
+		"1$":          `1\$`,
+	} {
+		if res := escape(s); res != expected {
+			t.Errorf("Expected %s, got %s", expected, res)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownBackend = errors.New("unknown backend")
+
+// Renderer writes an evaluated document in an output format, stopping with the error of ctx when ctx is done.
+type Renderer interface {
+	Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]Warning, error)
+}
+
+// RendererFunc lets a function be used as a Renderer.
+type RendererFunc func(ctx context.Context, w io.Writer, a *parser.Ast) ([]Warning, error)
+
+func (fn RendererFunc) Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]Warning, error) {
+	return fn(ctx, w, a)
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Renderer)
+	extensions = make(map[string]string) // extension -> backend name
+)
+
+// Register makes a backend available by name and by the file extensions given (with their leading dot).
+// Backends usually call it in their init function.
+// It panics if the name or one of the extensions is already registered.
+func Register(name string, r Renderer, exts ...string) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if r == nil {
+		panic("render: Register renderer is nil")
+	}
+	if _, ok := backends[name]; ok {
+		panic("render: Register called twice for backend " + name)
+	}
+	for _, ext := range exts {
+		if other, ok := extensions[strings.ToLower(ext)]; ok {
+			panic(fmt.Sprintf("render: extension %s of backend %s is already used by %s", ext, name, other))
+		}
+	}
+	backends[name] = r
+	for _, ext := range exts {
+		extensions[strings.ToLower(ext)] = name
+	}
+}
+
+// Lookup returns the backend registered with the given name.
+func Lookup(name string) (Renderer, error) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	r, ok := backends[name]
+	if !ok {
+		return nil, errors.Join(ErrUnknownBackend, fmt.Errorf("no backend named %s", name))
+	}
+	return r, nil
+}
+
+// ForFile returns the name of the backend registered for the extension of path.
+// The longest extension wins, so "figure.vl.json" may use another backend than "figure.json".
+func ForFile(path string) (string, error) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	base := strings.ToLower(filepath.Base(path))
+	name, best := "", 0
+	for ext, n := range extensions {
+		if len(ext) > best && strings.HasSuffix(base, ext) {
+			name, best = n, len(ext)
+		}
+	}
+	if name == "" {
+		return "", errors.Join(ErrUnknownBackend, fmt.Errorf("no backend for file %s", path))
+	}
+	return name, nil
+}
+
+// Backends returns the sorted names of the registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Extensions returns the sorted file extensions registered for a backend.
+func Extensions(name string) []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	var exts []string
+	for ext, n := range extensions {
+		if n == name {
+			exts = append(exts, ext)
+		}
+	}
+	slices.Sort(exts)
+	return exts
+}
@@ -0,0 +1,57 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"github.com/planklang/goplank/parser"
+	"io"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	called := false
+	Register("test", RendererFunc(func(context.Context, io.Writer, *parser.Ast) ([]Warning, error) {
+		called = true
+		return nil, nil
+	}), ".test", ".long.test")
+	Register("test-json", RendererFunc(func(context.Context, io.Writer, *parser.Ast) ([]Warning, error) {
+		return nil, nil
+	}), ".test.json")
+
+	r, err := Lookup("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.Render(context.Background(), io.Discard, new(parser.Ast)); err != nil || !called {
+		t.Error("Expected the registered renderer to be called, got", err)
+	}
+	if _, err = Lookup("missing"); !errors.Is(err, ErrUnknownBackend) {
+		t.Error("Expected ErrUnknownBackend, got", err)
+	}
+
+	for path, expected := range map[string]string{
+		"dir/figure.test":      "test",
+		"figure.LONG.TEST":     "test",
+		"figure.test.json":     "test-json",
+		"dir.test.json/figure": "",
+	} {
+		name, err := ForFile(path)
+		if name != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, path, name)
+		}
+		if expected == "" && !errors.Is(err, ErrUnknownBackend) {
+			t.Error("Expected ErrUnknownBackend, got", err)
+		}
+	}
+	exts := Extensions("test")
+	if len(exts) != 2 || exts[0] != ".long.test" {
+		t.Error("Expected [.long.test .test], got", exts)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic when an extension is registered twice")
+		}
+	}()
+	Register("other", RendererFunc(nil), ".test")
+}
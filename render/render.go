@@ -0,0 +1,72 @@
+package render
+
+import (
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"image/color"
+)
+
+// Warning reports a part of a figure that a backend cannot express exactly.
+type Warning struct {
+	Figure  int // index of the figure in the document
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("figure %d: %s", w.Figure+1, w.Message)
+}
+
+// Palette colors the plots without a color modifier, in order.
+var Palette = []color.RGBA{
+	{31, 119, 180, 255},
+	{255, 127, 14, 255},
+	{44, 160, 44, 255},
+	{214, 39, 40, 255},
+	{148, 103, 189, 255},
+	{140, 86, 75, 255},
+	{227, 119, 194, 255},
+	{127, 127, 127, 255},
+	{188, 189, 34, 255},
+	{23, 190, 207, 255},
+}
+
+// PlotColor returns the color of the i-th plot of a figure.
+func PlotColor(p *parser.Plot, i int) color.RGBA {
+	if p.Color != nil {
+		return *p.Color
+	}
+	return Palette[i%len(Palette)]
+}
+
+// Hex formats c as #rrggbb, ignoring its alpha.
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Opacity returns the alpha of c in [0, 1].
+func Opacity(c color.RGBA) float64 {
+	return float64(c.A) / 255
+}
+
+// NonPositive reports whether a plot has a value that a log scale on the axis cannot show.
+func NonPositive(f *parser.Figure, target string) bool {
+	a := f.Axis(target)
+	if a == nil || a.Scale != parser.ScaleLog {
+		return false
+	}
+	if a.HasRange() && (a.Range[0] <= 0 || a.Range[1] <= 0) {
+		return true
+	}
+	for _, p := range f.Plots {
+		values := p.X
+		if target == "y" {
+			values = p.Y
+		}
+		for _, v := range values {
+			if v <= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
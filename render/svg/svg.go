@@ -0,0 +1,366 @@
+package svg
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// size of a figure, in pixels
+const (
+	Width  = 640
+	Height = 400
+
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 20
+	marginBottom = 50
+
+	defaultWidth = 1.5
+	markerSize   = 3.5
+)
+
+var dashes = map[parser.Dash]string{
+	parser.DashDashed: "6 4",
+	parser.DashDotted: "1.5 3",
+}
+
+func init() {
+	render.Register("svg", render.RendererFunc(Render), ".svg")
+}
+
+// Render draws an evaluated document as an SVG image, the figures one below the other.
+func Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]render.Warning, error) {
+	var warns []render.Warning
+	var buf bytes.Buffer
+
+	n := max(len(a.Body), 1)
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		Width, Height*n, Width, Height*n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="white"/>`+"\n", Width, Height*n)
+	for i, f := range a.Body {
+		if err := ctx.Err(); err != nil {
+			return warns, err
+		}
+		fmt.Fprintf(&buf, `<g transform="translate(0 %d)">`+"\n", i*Height)
+		warns = append(warns, writeFigure(&buf, i, f)...)
+		buf.WriteString("</g>\n")
+	}
+	buf.WriteString("</svg>\n")
+
+	_, err := w.Write(buf.Bytes())
+	return warns, err
+}
+
+// scale maps values of an axis to pixels.
+type scale struct {
+	lo, hi   float64 // in log10 when log is set
+	log      bool
+	px0, px1 float64
+}
+
+func (s *scale) at(v float64) float64 {
+	if s.log {
+		v = math.Log10(v)
+	}
+	return s.px0 + (v-s.lo)/(s.hi-s.lo)*(s.px1-s.px0)
+}
+
+func (s *scale) valid(v float64) bool {
+	return !s.log || v > 0
+}
+
+func newScale(a *parser.Axis, values []float64, zero bool, px0, px1 float64) *scale {
+	s := &scale{log: a.Scale == parser.ScaleLog, px0: px0, px1: px1}
+	if a.HasRange() {
+		s.lo, s.hi = a.Range[0], a.Range[1]
+		if s.log {
+			s.lo, s.hi = math.Log10(max(s.lo, math.SmallestNonzeroFloat64)), math.Log10(max(s.hi, math.SmallestNonzeroFloat64))
+		}
+		return s
+	}
+	var vs []float64
+	for _, v := range values {
+		if s.valid(v) {
+			if s.log {
+				v = math.Log10(v)
+			}
+			vs = append(vs, v)
+		}
+	}
+	if zero && !s.log {
+		vs = append(vs, 0)
+	}
+	if len(vs) == 0 {
+		s.lo, s.hi = 0, 1
+		return s
+	}
+	s.lo, s.hi = slices.Min(vs), slices.Max(vs)
+	if s.lo == s.hi {
+		s.lo, s.hi = s.lo-1, s.hi+1
+	}
+	pad := (s.hi - s.lo) * 0.05
+	s.lo, s.hi = s.lo-pad, s.hi+pad
+	return s
+}
+
+// maxTicks bounds the ticks of an axis, whatever its range.
+const maxTicks = 20
+
+// ticks returns the values to mark on the axis and their labels, at most maxTicks.
+func (s *scale) ticks() ([]float64, []string) {
+	lo, hi := min(s.lo, s.hi), max(s.lo, s.hi)
+	var values []float64
+	var labels []string
+	if s.log && math.Floor(hi)-math.Ceil(lo) >= 1 && math.Floor(hi)-math.Ceil(lo) < maxTicks {
+		for e := math.Ceil(lo); e <= hi; e++ {
+			values = append(values, math.Pow(10, e))
+			labels = append(labels, strconv.FormatFloat(math.Pow(10, e), 'g', -1, 64))
+		}
+		return values, labels
+	}
+	add := func(v float64, label string) {
+		if s.log {
+			v = math.Pow(10, v)
+			label = strconv.FormatFloat(v, 'g', 3, 64)
+		}
+		values = append(values, v)
+		labels = append(labels, label)
+	}
+	raw := (hi - lo) / 5
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := mag
+	for _, m := range []float64{2, 5, 10} {
+		if raw/mag > m/1.5 {
+			step = m * mag
+		}
+	}
+	// a range tiny compared to its values has steps the floats cannot count: only its bounds are marked
+	first, last := math.Ceil(lo/step), math.Floor(hi/step+1e-9)
+	if !(step > 0) || math.IsInf(step, 0) || math.Abs(first) > 1<<53 || math.Abs(last) > 1<<53 || last-first+1 > maxTicks {
+		add(lo, strconv.FormatFloat(lo, 'g', 6, 64))
+		add(hi, strconv.FormatFloat(hi, 'g', 6, 64))
+		return values, labels
+	}
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	for k := range int(last-first) + 1 {
+		v := (first + float64(k)) * step
+		if v == 0 {
+			v = 0 // not -0
+		}
+		add(v, strconv.FormatFloat(v, 'f', decimals, 64))
+	}
+	return values, labels
+}
+
+func writeFigure(buf *bytes.Buffer, n int, f *parser.Figure) []render.Warning {
+	var warns []render.Warning
+
+	left, right := float64(marginLeft), float64(Width-marginRight)
+	top, bottom := float64(marginTop), float64(Height-marginBottom)
+
+	var xs, ys []float64
+	bars := false
+	for _, p := range f.Plots {
+		xs = append(xs, p.X...)
+		ys = append(ys, p.Y...)
+		bars = bars || p.Style == parser.StyleBar
+	}
+	axes := make(map[string]*parser.Axis)
+	for _, target := range []string{"x", "y"} {
+		axes[target] = f.Axis(target)
+		if axes[target] == nil {
+			axes[target] = &parser.Axis{Target: target, Scale: parser.ScaleLinear}
+		}
+		if render.NonPositive(f, target) {
+			warns = append(warns, render.Warning{Figure: n, Message: fmt.Sprintf("non-positive values are not drawn on the log scale of axis %s", target)})
+		}
+	}
+	if len(f.Plots) == 0 {
+		warns = append(warns, render.Warning{Figure: n, Message: "figure has no plot"})
+	}
+	sx := newScale(axes["x"], xs, false, left, right)
+	sy := newScale(axes["y"], ys, bars, bottom, top)
+
+	clip := fmt.Sprintf("plank-clip-%d", n+1)
+	fmt.Fprintf(buf, `<clipPath id="%s"><rect x="%g" y="%g" width="%g" height="%g"/></clipPath>`+"\n", clip, left, top, right-left, bottom-top)
+
+	// ticks and grid
+	values, labels := sx.ticks()
+	for i, v := range values {
+		x := sx.at(v)
+		if axes["x"].Grid {
+			fmt.Fprintf(buf, `<line x1="%.2f" y1="%g" x2="%.2f" y2="%g" stroke="#dddddd"/>`+"\n", x, top, x, bottom)
+		}
+		fmt.Fprintf(buf, `<line x1="%.2f" y1="%g" x2="%.2f" y2="%g" stroke="black"/>`+"\n", x, bottom, x, bottom+5)
+		fmt.Fprintf(buf, `<text x="%.2f" y="%g" text-anchor="middle">%s</text>`+"\n", x, bottom+18, escape(labels[i]))
+	}
+	values, labels = sy.ticks()
+	for i, v := range values {
+		y := sy.at(v)
+		if axes["y"].Grid {
+			fmt.Fprintf(buf, `<line x1="%g" y1="%.2f" x2="%g" y2="%.2f" stroke="#dddddd"/>`+"\n", left, y, right, y)
+		}
+		fmt.Fprintf(buf, `<line x1="%g" y1="%.2f" x2="%g" y2="%.2f" stroke="black"/>`+"\n", left-5, y, left, y)
+		fmt.Fprintf(buf, `<text x="%g" y="%.2f" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n", left-8, y, escape(labels[i]))
+	}
+	fmt.Fprintf(buf, `<rect x="%g" y="%g" width="%g" height="%g" fill="none" stroke="black"/>`+"\n", left, top, right-left, bottom-top)
+	if l := axes["x"].Label; l != "" {
+		fmt.Fprintf(buf, `<text x="%g" y="%g" text-anchor="middle">%s</text>`+"\n", (left+right)/2, float64(Height-12), escape(l))
+	}
+	if l := axes["y"].Label; l != "" {
+		fmt.Fprintf(buf, `<text transform="translate(16 %g) rotate(-90)" text-anchor="middle">%s</text>`+"\n", (top+bottom)/2, escape(l))
+	}
+
+	// plots
+	fmt.Fprintf(buf, `<g clip-path="url(#%s)">`+"\n", clip)
+	for i, p := range f.Plots {
+		writePlot(buf, p, i, sx, sy)
+	}
+	buf.WriteString("</g>\n")
+
+	writeLegend(buf, f, right)
+	return warns
+}
+
+func writePlot(buf *bytes.Buffer, p *parser.Plot, i int, sx, sy *scale) {
+	c := render.PlotColor(p, i)
+	paint := render.Hex(c)
+	opacity := ""
+	if c.A != 255 {
+		opacity = fmt.Sprintf(` opacity="%.3g"`, render.Opacity(c))
+	}
+	width := p.Width
+	if width == 0 {
+		width = defaultWidth
+	}
+
+	switch p.Style {
+	case parser.StyleBar:
+		half := barWidth(p, sx) / 2
+		base := sy.px0
+		if !sy.log && min(sy.lo, sy.hi) <= 0 && max(sy.lo, sy.hi) >= 0 {
+			base = sy.at(0)
+		}
+		for j := range p.X {
+			if !sx.valid(p.X[j]) || !sy.valid(p.Y[j]) {
+				continue
+			}
+			x, y := sx.at(p.X[j]), sy.at(p.Y[j])
+			fmt.Fprintf(buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"%s/>`+"\n", x-half, min(y, base), 2*half, math.Abs(base-y), paint, opacity)
+		}
+		return
+	case parser.StyleLine:
+		var points []string
+		for j := range p.X {
+			if sx.valid(p.X[j]) && sy.valid(p.Y[j]) {
+				points = append(points, fmt.Sprintf("%.2f,%.2f", sx.at(p.X[j]), sy.at(p.Y[j])))
+			}
+		}
+		dash := ""
+		if d, ok := dashes[p.Dash]; ok {
+			dash = fmt.Sprintf(` stroke-dasharray="%s"`, d)
+		}
+		fmt.Fprintf(buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%g"%s%s/>`+"\n", strings.Join(points, " "), paint, width, dash, opacity)
+		if p.Marker == parser.MarkerNone {
+			return
+		}
+	}
+
+	marker := p.Marker
+	if marker == parser.MarkerNone { // scatter
+		marker = parser.MarkerCircle
+	}
+	for j := range p.X {
+		if sx.valid(p.X[j]) && sy.valid(p.Y[j]) {
+			writeMarker(buf, marker, sx.at(p.X[j]), sy.at(p.Y[j]), paint, opacity)
+		}
+	}
+}
+
+// barWidth returns the width of the bars of p, in pixels.
+func barWidth(p *parser.Plot, sx *scale) float64 {
+	var px []float64
+	for _, x := range p.X {
+		if sx.valid(x) {
+			px = append(px, sx.at(x))
+		}
+	}
+	slices.Sort(px)
+	gap := math.Abs(sx.px1-sx.px0) / 10
+	for j := 1; j < len(px); j++ {
+		if d := px[j] - px[j-1]; d > 0 {
+			gap = min(gap, d)
+		}
+	}
+	return gap * 0.8
+}
+
+func writeMarker(buf *bytes.Buffer, m parser.Marker, x, y float64, paint, opacity string) {
+	const r = markerSize
+	switch m {
+	case parser.MarkerSquare:
+		fmt.Fprintf(buf, `<rect x="%.2f" y="%.2f" width="%g" height="%g" fill="%s"%s/>`+"\n", x-r, y-r, 2*r, 2*r, paint, opacity)
+	case parser.MarkerTriangle:
+		fmt.Fprintf(buf, `<polygon points="%.2f,%.2f %.2f,%.2f %.2f,%.2f" fill="%s"%s/>`+"\n", x, y-r*1.2, x-r*1.2, y+r, x+r*1.2, y+r, paint, opacity)
+	case parser.MarkerDiamond:
+		fmt.Fprintf(buf, `<polygon points="%.2f,%.2f %.2f,%.2f %.2f,%.2f %.2f,%.2f" fill="%s"%s/>`+"\n", x, y-r*1.3, x+r, y, x, y+r*1.3, x-r, y, paint, opacity)
+	case parser.MarkerPlus:
+		fmt.Fprintf(buf, `<path d="M%.2f %.2fH%.2fM%.2f %.2fV%.2f" stroke="%s" stroke-width="1.5"%s/>`+"\n", x-r, y, x+r, x, y-r, y+r, paint, opacity)
+	default:
+		fmt.Fprintf(buf, `<circle cx="%.2f" cy="%.2f" r="%g" fill="%s"%s/>`+"\n", x, y, r, paint, opacity)
+	}
+}
+
+func writeLegend(buf *bytes.Buffer, f *parser.Figure, right float64) {
+	var plots []int
+	for i, p := range f.Plots {
+		if p.Label != "" {
+			plots = append(plots, i)
+		}
+	}
+	if len(plots) == 0 {
+		return
+	}
+	longest := 0
+	for _, i := range plots {
+		longest = max(longest, len([]rune(f.Plots[i].Label)))
+	}
+	w := 40 + float64(longest)*7
+	x := right - w - 10
+	y := float64(marginTop) + 10
+	fmt.Fprintf(buf, `<rect x="%.2f" y="%g" width="%.2f" height="%d" fill="white" fill-opacity="0.8" stroke="#999999"/>`+"\n", x, y, w, 8+18*len(plots))
+	for k, i := range plots {
+		p := f.Plots[i]
+		c := render.PlotColor(p, i)
+		ly := y + 13 + float64(18*k)
+		switch p.Style {
+		case parser.StyleLine:
+			fmt.Fprintf(buf, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="2"/>`+"\n", x+6, ly, x+26, ly, render.Hex(c))
+		case parser.StyleBar:
+			fmt.Fprintf(buf, `<rect x="%.2f" y="%.2f" width="20" height="8" fill="%s"/>`+"\n", x+6, ly-4, render.Hex(c))
+		}
+		if p.Style == parser.StyleScatter || p.Marker != parser.MarkerNone && p.Style == parser.StyleLine {
+			marker := p.Marker
+			if marker == parser.MarkerNone {
+				marker = parser.MarkerCircle
+			}
+			writeMarker(buf, marker, x+16, ly, render.Hex(c), "")
+		}
+		fmt.Fprintf(buf, `<text x="%.2f" y="%.2f" dominant-baseline="middle">%s</text>`+"\n", x+32, ly, escape(p.Label))
+	}
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s)) // never fails on a strings.Builder
+	return b.String()
+}
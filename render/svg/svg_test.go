@@ -0,0 +1,88 @@
+package svg
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"github.com/planklang/goplank/internal/planktest"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	warns, err := Render(context.Background(), &buf, planktest.Eval(t, "axis x 'Time <s>' [0 10]\naxis y | grid\nplot [1 2 3] [3 4 2] 'a & b' | color red | dash dashed | marker square\nplot [1 2] | style bar\n---\naxis y | scale log\nplot [1 10 100] | style scatter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 0 {
+		t.Error("Expected no warnings, got", warns)
+	}
+	out := buf.String()
+	// the output must be well-formed XML
+	dec := xml.NewDecoder(strings.NewReader(out))
+	for {
+		if _, err = dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err, out)
+		}
+	}
+	for _, s := range []string{
+		`height="800"`,
+		"Time &lt;s&gt;",
+		"a &amp; b",
+		`stroke="#ff0000" stroke-width="1.5" stroke-dasharray="6 4"`,
+		`<g transform="translate(0 400)">`,
+		">100</text>",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in\n%s", s, out)
+		}
+	}
+}
+
+func TestTicks(t *testing.T) {
+	s := &scale{lo: 0, hi: 10, px0: 0, px1: 100}
+	values, labels := s.ticks()
+	if len(values) != 6 || labels[1] != "2" || labels[5] != "10" {
+		t.Error("Expected 0 to 10 by 2, got", labels)
+	}
+	s = &scale{lo: 0, hi: 0.3, px0: 0, px1: 100}
+	_, labels = s.ticks()
+	if len(labels) != 7 || labels[1] != "0.05" {
+		t.Error("Expected 0 to 0.3 by 0.05, got", labels)
+	}
+	if x := s.at(0.15); x != 50 {
+		t.Error("Expected 50, got", x)
+	}
+}
+
+func TestTicksTinyRange(t *testing.T) {
+	for _, src := range []string{"plot [1.0 1.0000000000000002] [1 2]", "axis x [1.0 1.0000000000000002]\nplot [1 2]"} {
+		tree := planktest.Eval(t, src)
+		done := make(chan error)
+		go func() {
+			_, err := Render(context.Background(), io.Discard, tree)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error("Expected", src, "to render, got", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected", src, "to render, got no end")
+		}
+	}
+	s := &scale{lo: 1, hi: 1.0000000000000002, px0: 0, px1: 100}
+	if values, _ := s.ticks(); len(values) != 2 {
+		t.Error("Expected the bounds of the range, got", values)
+	}
+	s = &scale{lo: -300, hi: 300, px0: 0, px1: 100, log: true}
+	if values, _ := s.ticks(); len(values) > maxTicks {
+		t.Error("Expected at most", maxTicks, "ticks, got", len(values))
+	}
+}
@@ -0,0 +1,250 @@
+package text
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// size of the plotting area of a figure, in characters
+const (
+	Width  = 64
+	Height = 16
+)
+
+var (
+	// symbols of the plots without marker, by index
+	symbols = []rune{'*', '+', 'x', 'o', '#', '%', '@', '&', '=', '~'}
+	markers = map[parser.Marker]rune{
+		parser.MarkerCircle:   'o',
+		parser.MarkerSquare:   '#',
+		parser.MarkerTriangle: '^',
+		parser.MarkerDiamond:  'd',
+		parser.MarkerPlus:     '+',
+	}
+)
+
+func init() {
+	render.Register("text", render.RendererFunc(Render), ".txt")
+}
+
+// Render draws an evaluated document with characters, for a terminal. Each plot is drawn with its own symbol, the
+// colors, widths and dashes are ignored.
+func Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]render.Warning, error) {
+	var warns []render.Warning
+	var buf bytes.Buffer
+
+	for i, f := range a.Body {
+		if err := ctx.Err(); err != nil {
+			return warns, err
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if len(a.Body) > 1 {
+			fmt.Fprintf(&buf, "figure %d\n", i+1)
+		}
+		warns = append(warns, writeFigure(&buf, i, f)...)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return warns, err
+}
+
+// scale maps values of an axis to cells.
+type scale struct {
+	lo, hi float64 // in log10 when log is set
+	log    bool
+	cells  int
+}
+
+// at returns the cell of v, or -1 if v is not on the axis.
+func (s *scale) at(v float64) int {
+	if s.log {
+		if v <= 0 {
+			return -1
+		}
+		v = math.Log10(v)
+	}
+	c := int(math.Round((v - s.lo) / (s.hi - s.lo) * float64(s.cells-1)))
+	if c < 0 || c >= s.cells {
+		return -1
+	}
+	return c
+}
+
+// value returns the value at cell c.
+func (s *scale) value(c int) float64 {
+	v := s.lo + float64(c)/float64(s.cells-1)*(s.hi-s.lo)
+	if s.log {
+		return math.Pow(10, v)
+	}
+	return v
+}
+
+func newScale(a *parser.Axis, values []float64, zero bool, cells int) *scale {
+	s := &scale{log: a.Scale == parser.ScaleLog, cells: cells}
+	if a.HasRange() && (!s.log || min(a.Range[0], a.Range[1]) > 0) {
+		s.lo, s.hi = a.Range[0], a.Range[1]
+		if s.log {
+			s.lo, s.hi = math.Log10(s.lo), math.Log10(s.hi)
+		}
+		return s
+	}
+	var vs []float64
+	for _, v := range values {
+		if !s.log {
+			vs = append(vs, v)
+		} else if v > 0 {
+			vs = append(vs, math.Log10(v))
+		}
+	}
+	if zero && !s.log {
+		vs = append(vs, 0)
+	}
+	if len(vs) == 0 {
+		s.lo, s.hi = 0, 1
+		return s
+	}
+	s.lo, s.hi = slices.Min(vs), slices.Max(vs)
+	if s.lo == s.hi {
+		s.lo, s.hi = s.lo-1, s.hi+1
+	}
+	return s
+}
+
+func writeFigure(buf *bytes.Buffer, n int, f *parser.Figure) []render.Warning {
+	var warns []render.Warning
+	if len(f.Plots) == 0 {
+		warns = append(warns, render.Warning{Figure: n, Message: "figure has no plot"})
+	}
+
+	var xs, ys []float64
+	bars := false
+	for _, p := range f.Plots {
+		xs = append(xs, p.X...)
+		ys = append(ys, p.Y...)
+		bars = bars || p.Style == parser.StyleBar
+	}
+	axes := map[string]*parser.Axis{}
+	for _, target := range []string{"x", "y"} {
+		axes[target] = f.Axis(target)
+		if axes[target] == nil {
+			axes[target] = &parser.Axis{Target: target}
+		}
+		if render.NonPositive(f, target) {
+			warns = append(warns, render.Warning{Figure: n, Message: fmt.Sprintf("non-positive values are not drawn on the log scale of axis %s", target)})
+		}
+	}
+	sx := newScale(axes["x"], xs, false, Width)
+	sy := newScale(axes["y"], ys, bars, Height)
+
+	grid := make([][]rune, Height)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", Width))
+	}
+	set := func(c, r int, sym rune) {
+		if c >= 0 && r >= 0 {
+			grid[Height-1-r][c] = sym
+		}
+	}
+	if axes["y"].Grid {
+		for r := 0; r < Height; r += Height / 4 {
+			for c := range Width {
+				set(c, r, '.')
+			}
+		}
+	}
+	if axes["x"].Grid {
+		for c := 0; c < Width; c += Width / 8 {
+			for r := range Height {
+				set(c, r, '.')
+			}
+		}
+	}
+
+	for i, p := range f.Plots {
+		sym := symbol(p, i)
+		switch p.Style {
+		case parser.StyleBar:
+			base := max(sy.at(0), 0)
+			for j := range p.X {
+				c, top := sx.at(p.X[j]), sy.at(p.Y[j])
+				if c < 0 || top < 0 {
+					continue
+				}
+				for r := min(base, top); r <= max(base, top); r++ {
+					set(c, r, sym)
+				}
+			}
+		case parser.StyleScatter:
+			for j := range p.X {
+				set(sx.at(p.X[j]), sy.at(p.Y[j]), sym)
+			}
+		default:
+			for j := 1; j < len(p.X); j++ { // samples the segments, one point per column
+				c0, c1 := sx.at(p.X[j-1]), sx.at(p.X[j])
+				if c0 < 0 || c1 < 0 || sy.at(p.Y[j-1]) < 0 || sy.at(p.Y[j]) < 0 {
+					continue
+				}
+				for c := min(c0, c1); c <= max(c0, c1); c++ {
+					t := 0.0
+					if c1 != c0 {
+						t = float64(c-c0) / float64(c1-c0)
+					}
+					set(c, sy.at(p.Y[j-1]+t*(p.Y[j]-p.Y[j-1])), sym)
+				}
+			}
+			for j := range p.X {
+				set(sx.at(p.X[j]), sy.at(p.Y[j]), sym)
+			}
+		}
+	}
+
+	// rows, with the y ticks at the top, the middle and the bottom
+	yTicks := map[int]string{Height - 1: number(sy.value(Height - 1)), Height / 2: number(sy.value(Height / 2)), 0: number(sy.value(0))}
+	tickWidth := 0
+	for _, t := range yTicks {
+		tickWidth = max(tickWidth, len(t))
+	}
+	if l := axes["y"].Label; l != "" {
+		fmt.Fprintf(buf, "%s\n", l)
+	}
+	for i, row := range grid {
+		fmt.Fprintf(buf, "%*s |%s\n", tickWidth, yTicks[Height-1-i], strings.TrimRight(string(row), " "))
+	}
+	fmt.Fprintf(buf, "%*s +%s\n", tickWidth, "", strings.Repeat("-", Width))
+	lo, hi := number(sx.value(0)), number(sx.value(Width-1))
+	fmt.Fprintf(buf, "%*s  %s%*s\n", tickWidth, "", lo, Width-len(lo), hi)
+	if l := axes["x"].Label; l != "" {
+		fmt.Fprintf(buf, "%*s  %*s\n", tickWidth, "", (Width+len(l))/2, l)
+	}
+
+	for i, p := range f.Plots {
+		if p.Label != "" {
+			fmt.Fprintf(buf, "  %c %s\n", symbol(p, i), p.Label)
+		}
+	}
+	return warns
+}
+
+func symbol(p *parser.Plot, i int) rune {
+	if m, ok := markers[p.Marker]; ok {
+		return m
+	}
+	return symbols[i%len(symbols)]
+}
+
+func number(f float64) string {
+	if f == 0 {
+		f = 0 // normalizes -0
+	}
+	return strconv.FormatFloat(f, 'g', 4, 64)
+}
@@ -0,0 +1,49 @@
+package text
+
+import (
+	"bytes"
+	"context"
+	"github.com/planklang/goplank/internal/planktest"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	warns, err := Render(context.Background(), &buf, planktest.Eval(t, "axis x 'time'\nplot [0 1 2] [0 1 2] 'a'\nplot [0 2] [2 0] 'b' | style scatter | marker square"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 0 {
+		t.Error("Expected no warnings, got", warns)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < Height+5 {
+		t.Fatal("Expected the rows of the figure, got", buf.String())
+	}
+	if top := strings.TrimSpace(lines[0]); !strings.HasPrefix(top, "2 |#   ") || !strings.HasSuffix(top, " ***") {
+		t.Errorf("Expected the top row, got %q", lines[0])
+	}
+	if bottom := strings.TrimSpace(lines[Height-1]); !strings.HasPrefix(bottom, "0 |*** ") || !strings.HasSuffix(bottom, "   #") {
+		t.Errorf("Expected the bottom row, got %q", lines[Height-1])
+	}
+	for _, s := range []string{"time\n", "  * a\n", "  # b\n"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Expected %q in\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestRenderWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	warns, err := Render(context.Background(), &buf, planktest.Eval(t, "axis y | scale log\nplot [0 1]\n---\naxis x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 2 || warns[0].Figure != 0 || warns[1].String() != "figure 2: figure has no plot" {
+		t.Error("Expected 2 warnings, got", warns)
+	}
+	if !strings.HasPrefix(buf.String(), "figure 1\n") {
+		t.Error("Expected the figures to be numbered, got", buf.String())
+	}
+}
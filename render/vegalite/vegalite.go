@@ -0,0 +1,250 @@
+package vegalite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/planklang/goplank/parser"
+	"github.com/planklang/goplank/render"
+	"io"
+)
+
+const Schema = "https://vega.github.io/schema/vega-lite/v5.json"
+
+type Spec struct {
+	Schema   string    `json:"$schema,omitempty"`
+	Data     *Data     `json:"data,omitempty"`
+	Mark     *Mark     `json:"mark,omitempty"`
+	Encoding *Encoding `json:"encoding,omitempty"`
+	Layer    []*Spec   `json:"layer,omitempty"`
+	VConcat  []*Spec   `json:"vconcat,omitempty"`
+}
+
+type Data struct {
+	Values []Point `json:"values"`
+}
+
+type Point struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Series string  `json:"series"`
+	Order  int     `json:"order"`
+}
+
+type Mark struct {
+	Type        string    `json:"type"`
+	StrokeWidth float64   `json:"strokeWidth,omitempty"`
+	StrokeDash  []float64 `json:"strokeDash,omitempty"`
+	Shape       string    `json:"shape,omitempty"`
+	Filled      bool      `json:"filled,omitempty"`
+	Point       *Overlay  `json:"point,omitempty"`
+}
+
+// Overlay is the mark of the markers drawn on a line.
+type Overlay struct {
+	Shape  string `json:"shape"`
+	Filled bool   `json:"filled"`
+}
+
+type Encoding struct {
+	X     *Channel `json:"x,omitempty"`
+	Y     *Channel `json:"y,omitempty"`
+	Color *Channel `json:"color,omitempty"`
+	Order *Channel `json:"order,omitempty"`
+}
+
+type Channel struct {
+	Field  string          `json:"field"`
+	Type   string          `json:"type"`
+	Axis   *Axis           `json:"axis,omitempty"`
+	Scale  *Scale          `json:"scale,omitempty"`
+	Legend json.RawMessage `json:"legend,omitempty"`
+}
+
+type Axis struct {
+	Title string `json:"title,omitempty"`
+	Grid  bool   `json:"grid"`
+}
+
+type Scale struct {
+	Type   string   `json:"type,omitempty"`
+	Domain []any    `json:"domain,omitempty"`
+	Range  []string `json:"range,omitempty"`
+	Zero   *bool    `json:"zero,omitempty"`
+}
+
+var (
+	dashes = map[parser.Dash][]float64{
+		parser.DashDashed: {6, 4},
+		parser.DashDotted: {1, 3},
+	}
+	shapes = map[parser.Marker]string{
+		parser.MarkerCircle:   "circle",
+		parser.MarkerSquare:   "square",
+		parser.MarkerTriangle: "triangle-up",
+		parser.MarkerDiamond:  "diamond",
+		parser.MarkerPlus:     "cross",
+	}
+)
+
+func init() {
+	render.Register("vegalite", render.RendererFunc(Render), ".vl.json", ".vl")
+}
+
+// Render writes the Vega-Lite specification of an evaluated document to w.
+func Render(ctx context.Context, w io.Writer, a *parser.Ast) ([]render.Warning, error) {
+	spec, warns, err := convert(ctx, a)
+	if err != nil {
+		return warns, err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return warns, enc.Encode(spec)
+}
+
+// Convert returns the Vega-Lite specification of an evaluated document.
+// A document with several figures becomes a vertical concatenation.
+func Convert(a *parser.Ast) (*Spec, []render.Warning) {
+	spec, warns, _ := convert(context.Background(), a)
+	return spec, warns
+}
+
+// convert returns the specification of a like Convert, stopping with the error of ctx when ctx is done.
+func convert(ctx context.Context, a *parser.Ast) (*Spec, []render.Warning, error) {
+	var warns []render.Warning
+	var figs []*Spec
+	for i, f := range a.Body {
+		if err := ctx.Err(); err != nil {
+			return nil, warns, err
+		}
+		spec, ws := convertFigure(i, f)
+		figs = append(figs, spec)
+		warns = append(warns, ws...)
+	}
+	if len(figs) == 1 {
+		figs[0].Schema = Schema
+		return figs[0], warns, nil
+	}
+	return &Spec{Schema: Schema, VConcat: figs}, warns, nil
+}
+
+func convertFigure(n int, f *parser.Figure) (*Spec, []render.Warning) {
+	var warns []render.Warning
+	warn := func(format string, args ...any) {
+		warns = append(warns, render.Warning{Figure: n, Message: fmt.Sprintf(format, args...)})
+	}
+
+	bars := false
+	for _, p := range f.Plots {
+		bars = bars || p.Style == parser.StyleBar
+	}
+	spec := &Spec{Encoding: &Encoding{
+		X: axisChannel(f.Axis("x"), "x", false),
+		Y: axisChannel(f.Axis("y"), "y", bars),
+	}}
+	for _, target := range []string{"x", "y"} {
+		if render.NonPositive(f, target) {
+			warn("Vega-Lite drops non-positive values on the log scale of axis %s", target)
+		}
+	}
+	if len(f.Plots) == 0 {
+		warn("figure has no plot")
+		spec.Data = &Data{Values: []Point{}}
+		spec.Mark = &Mark{Type: "point"}
+		return spec, warns
+	}
+
+	// Vega-Lite identifies a series by the value of a field, so labels must be unique
+	color := &Channel{Field: "series", Type: "nominal", Scale: new(Scale)}
+	labelled := false
+	used := make(map[string]bool)
+	for i, p := range f.Plots {
+		name := p.Label
+		labelled = labelled || name != ""
+		if name == "" {
+			name = fmt.Sprintf("series %d", i+1)
+		}
+		if used[name] {
+			unique := name
+			for k := 2; used[unique]; k++ {
+				unique = fmt.Sprintf("%s (%d)", name, k)
+			}
+			warn("label %q is used by several plots, renamed to %q because Vega-Lite would merge them", name, unique)
+			name = unique
+		}
+		used[name] = true
+
+		c := render.PlotColor(p, i)
+		css := render.Hex(c)
+		if c.A != 255 {
+			css = fmt.Sprintf("rgba(%d, %d, %d, %.3g)", c.R, c.G, c.B, render.Opacity(c))
+		}
+		color.Scale.Domain = append(color.Scale.Domain, name)
+		color.Scale.Range = append(color.Scale.Range, css)
+
+		layer := &Spec{Data: &Data{Values: make([]Point, len(p.X))}, Mark: plotMark(p, warn)}
+		for j := range p.X {
+			layer.Data.Values[j] = Point{X: p.X[j], Y: p.Y[j], Series: name, Order: j}
+		}
+		if p.Style == parser.StyleLine {
+			layer.Encoding = &Encoding{Order: &Channel{Field: "order", Type: "quantitative"}} // keep the order of the points
+		}
+		spec.Layer = append(spec.Layer, layer)
+	}
+	if !labelled {
+		color.Legend = json.RawMessage("null")
+	}
+	spec.Encoding.Color = color
+	return spec, warns
+}
+
+func axisChannel(a *parser.Axis, field string, zero bool) *Channel {
+	c := &Channel{Field: field, Type: "quantitative", Axis: &Axis{Title: field}, Scale: &Scale{Zero: &zero}}
+	if a == nil {
+		return c
+	}
+	if a.Label != "" {
+		c.Axis.Title = a.Label
+	}
+	c.Axis.Grid = a.Grid
+	if a.Scale == parser.ScaleLog {
+		c.Scale.Type = "log"
+	}
+	if a.HasRange() {
+		c.Scale.Domain = []any{a.Range[0], a.Range[1]}
+	}
+	return c
+}
+
+// plotMark returns the mark of p, with warn called for each modifier Vega-Lite cannot draw on it.
+func plotMark(p *parser.Plot, warn func(format string, args ...any)) *Mark {
+	m := &Mark{StrokeWidth: p.Width}
+	switch p.Style {
+	case parser.StyleScatter:
+		m.Type = "point"
+		m.Shape = shapes[p.Marker]
+		if m.Shape == "" {
+			m.Shape = shapes[parser.MarkerCircle]
+		}
+		m.Filled = true
+	case parser.StyleBar:
+		m.Type = "bar"
+		m.StrokeWidth = 0 // the width of a line, not of the outline of the bars
+		if p.Width != 0 {
+			warn("Vega-Lite has no line width for bar plots, width %g ignored", p.Width)
+		}
+		if p.Marker != parser.MarkerNone {
+			warn("Vega-Lite has no marker for bar plots, marker %s ignored", p.Marker)
+		}
+	default:
+		m.Type = "line"
+		m.StrokeDash = dashes[p.Dash]
+		if p.Marker != parser.MarkerNone {
+			m.Point = &Overlay{Shape: shapes[p.Marker], Filled: true}
+		}
+	}
+	if p.Style != parser.StyleLine && p.Dash != parser.DashSolid {
+		warn("Vega-Lite has no dash for %s plots, dash %s ignored", p.Style, p.Dash)
+	}
+	return m
+}
@@ -0,0 +1,88 @@
+package vegalite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/planklang/goplank/internal/planktest"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	spec, warns := Convert(planktest.Eval(t, "axis x 'Time' [0 10]\nplot [1 2] [3 4] 'a' | color red | width 2 | dash dashed\nplot [1 2] | style scatter | marker square"))
+	if len(warns) != 0 {
+		t.Error("Expected no warnings, got", warns)
+	}
+	if spec.Schema != Schema {
+		t.Error("Expected schema, got", spec.Schema)
+	}
+	if spec.Encoding.X.Axis.Title != "Time" {
+		t.Error("Expected Time, got", spec.Encoding.X.Axis.Title)
+	}
+	if len(spec.Encoding.X.Scale.Domain) != 2 {
+		t.Error("Expected domain [0 10], got", spec.Encoding.X.Scale.Domain)
+	}
+	if len(spec.Layer) != 2 {
+		t.Fatal("Expected 2, got", len(spec.Layer))
+	}
+	line := spec.Layer[0].Mark
+	if line.Type != "line" || line.StrokeWidth != 2 || len(line.StrokeDash) != 2 {
+		t.Error("Expected dashed line of width 2, got", line)
+	}
+	if spec.Encoding.Color.Scale.Range[0] != "#ff0000" {
+		t.Error("Expected #ff0000, got", spec.Encoding.Color.Scale.Range[0])
+	}
+	point := spec.Layer[1].Mark
+	if point.Type != "point" || point.Shape != "square" {
+		t.Error("Expected square points, got", point)
+	}
+	if len(spec.Layer[1].Data.Values) != 2 || spec.Layer[1].Data.Values[1].X != 1 {
+		t.Error("Expected 2 points, got", spec.Layer[1].Data.Values)
+	}
+
+	spec, _ = Convert(planktest.Eval(t, "plot [1]\n---\nplot [2]"))
+	if len(spec.VConcat) != 2 || spec.VConcat[0].Schema != "" {
+		t.Error("Expected 2 concatenated figures, got", spec.VConcat)
+	}
+}
+
+func TestConvertWarnings(t *testing.T) {
+	_, warns := Convert(planktest.Eval(t, "plot [1 2] 'a'\nplot [3 4] 'a'"))
+	if len(warns) != 1 || !strings.Contains(warns[0].Message, "a (2)") {
+		t.Error("Expected a warning about the duplicated label, got", warns)
+	}
+	_, warns = Convert(planktest.Eval(t, "axis y | scale log\nplot [0 1]"))
+	if len(warns) != 1 {
+		t.Error("Expected a warning about the log scale, got", warns)
+	}
+
+	for _, c := range []struct{ src, warning string }{
+		{"plot [1 2] | style scatter | dash dashed", "dash dashed"},
+		{"plot [1 2] | style bar | dash dotted", "dash dotted"},
+		{"plot [1 2] | style bar | marker square", "marker square"},
+		{"plot [1 2] | style bar | width 3", "width 3"},
+	} {
+		spec, warns := Convert(planktest.Eval(t, c.src))
+		if len(warns) != 1 || !strings.Contains(warns[0].Message, c.warning) {
+			t.Error("Expected a warning about", c.warning, "got", warns)
+		}
+		if m := spec.Layer[0].Mark; m.StrokeWidth != 0 || m.StrokeDash != nil || m.Point != nil {
+			t.Error("Expected no width, dash or marker, got", m)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Render(context.Background(), &buf, planktest.Eval(t, "plot [1 2] 'a'")); err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["$schema"] != Schema {
+		t.Error("Expected schema, got", m["$schema"])
+	}
+}
@@ -0,0 +1,173 @@
+// Package token represents the positions in the source files of a document: the document and the files it includes.
+// Like go/token, a FileSet registers the files and turns the compact positions, Pos, into file, line and column.
+package token
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Pos is a compact position in the files of a FileSet: the base of a file plus a byte offset in it. It takes the
+// memory of an int, and the FileSet converts it to a Position.
+type Pos int
+
+// NoPos is the zero Pos, in no file.
+const NoPos Pos = 0
+
+// IsValid returns true if p is a position in a file.
+func (p Pos) IsValid() bool {
+	return p != NoPos
+}
+
+// Position is a position in a source file.
+type Position struct {
+	File   string // the name of the file, empty when it has none
+	Offset int    // starting at 0, in bytes
+	Line   int    // starting at 0
+	Column int    // starting at 0, in bytes
+}
+
+// String returns the position as file:line:col, with the line and the column starting at 1, or as line:col when the
+// file has no name.
+func (p Position) String() string {
+	s := fmt.Sprintf("%d:%d", p.Line+1, p.Column+1)
+	if p.File != "" {
+		s = p.File + ":" + s
+	}
+	return s
+}
+
+// File is a source file registered in a FileSet.
+type File struct {
+	name  string
+	base  int
+	src   string
+	lines []int // the offset of the start of each line
+}
+
+// Name returns the name of f, as given to FileSet.AddFile.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Base returns the Pos of the first byte of f.
+func (f *File) Base() int {
+	return f.base
+}
+
+// Size returns the size of f in bytes.
+func (f *File) Size() int {
+	return len(f.src)
+}
+
+// Source returns the content of f.
+func (f *File) Source() string {
+	return f.src
+}
+
+// LineCount returns the number of lines of f.
+func (f *File) LineCount() int {
+	return len(f.lines)
+}
+
+// LineStart returns the offset of the start of the line, starting at 0. It panics if f has no such line.
+func (f *File) LineStart(line int) int {
+	return f.lines[line]
+}
+
+// Pos returns the Pos of the byte offset of f. The offset may be the size of f, just after its last byte.
+func (f *File) Pos(offset int) Pos {
+	if offset < 0 || offset > len(f.src) {
+		panic(fmt.Sprintf("invalid offset %d in %s of size %d", offset, f.name, len(f.src)))
+	}
+	return Pos(f.base + offset)
+}
+
+// Offset returns the byte offset of p in f.
+func (f *File) Offset(p Pos) int {
+	return int(p) - f.base
+}
+
+// Position returns the position of the byte offset of f.
+func (f *File) Position(offset int) Position {
+	line, _ := slices.BinarySearch(f.lines, offset+1) // the first line starting after offset
+	line--
+	return Position{File: f.name, Offset: offset, Line: line, Column: offset - f.lines[line]}
+}
+
+// FileSet is a set of source files. It is safe for concurrent use.
+type FileSet struct {
+	mu    sync.RWMutex
+	base  int
+	files []*File // by base
+}
+
+// NewFileSet returns an empty set.
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1} // NoPos is in no file
+}
+
+// AddFile registers the source src of the file name and returns it. Registering a name again adds another file,
+// found by Lookup from then on, see Lookup.
+func (s *FileSet) AddFile(name, src string) *File {
+	f := &File{name: name, src: src, lines: []int{0}}
+	for i := strings.IndexByte(src, '\n'); i >= 0; {
+		f.lines = append(f.lines, f.lines[len(f.lines)-1]+i+1)
+		i = strings.IndexByte(src[f.lines[len(f.lines)-1]:], '\n')
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f.base = s.base
+	s.base += len(src) + 1 // a Pos for the end of the file
+	s.files = append(s.files, f)
+	return f
+}
+
+// File returns the file containing p, or nil if p is in no file of s.
+func (s *FileSet) File(p Pos) *File {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i, found := slices.BinarySearchFunc(s.files, int(p), func(f *File, p int) int { return f.base - p })
+	if !found {
+		i--
+	}
+	if i < 0 || int(p) > s.files[i].base+len(s.files[i].src) {
+		return nil
+	}
+	return s.files[i]
+}
+
+// Lookup returns the last file of s registered with name, or nil if there is none. A Position only has the name of
+// its file, so that the positions in a file registered before another one with the same name are resolved in the last
+// one: register the sources of a name again only when they are the same, like a file included twice, or when the
+// former ones are no longer used, like the inputs of a REPL. The File of a Pos is found by File instead.
+func (s *FileSet) Lookup(name string) *File {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.files) - 1; i >= 0; i-- {
+		if s.files[i].name == name {
+			return s.files[i]
+		}
+	}
+	return nil
+}
+
+// Position returns the position of p, the zero Position if p is in no file of s.
+func (s *FileSet) Position(p Pos) Position {
+	f := s.File(p)
+	if f == nil {
+		return Position{}
+	}
+	return f.Position(f.Offset(p))
+}
+
+// Pos returns the compact form of pos, a position in a file of s, or NoPos if s has no such file.
+func (s *FileSet) Pos(pos Position) Pos {
+	f := s.Lookup(pos.File)
+	if f == nil || pos.Offset < 0 || pos.Offset > f.Size() {
+		return NoPos
+	}
+	return f.Pos(pos.Offset)
+}
@@ -0,0 +1,74 @@
+package token
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFileSet(t *testing.T) {
+	fset := NewFileSet()
+	a := fset.AddFile("a.plank", "plot [1 2]\naxis x\n")
+	b := fset.AddFile("b.plank", "let c red")
+	if a.LineCount() != 3 || b.LineCount() != 1 || a.LineStart(1) != 11 {
+		t.Error("Expected 3 and 1 lines, got", a.LineCount(), b.LineCount(), a.LineStart(1))
+	}
+	for _, tc := range []struct {
+		p    Pos
+		want Position
+	}{
+		{a.Pos(0), Position{File: "a.plank", Offset: 0, Line: 0, Column: 0}},
+		{a.Pos(16), Position{File: "a.plank", Offset: 16, Line: 1, Column: 5}},
+		{a.Pos(18), Position{File: "a.plank", Offset: 18, Line: 2, Column: 0}}, // the end of a
+		{b.Pos(4), Position{File: "b.plank", Offset: 4, Line: 0, Column: 4}},
+		{NoPos, Position{}},
+		{b.Pos(b.Size()) + 1, Position{}},
+	} {
+		if pos := fset.Position(tc.p); pos != tc.want {
+			t.Errorf("Expected %v for %d, got %v", tc.want, tc.p, pos)
+		}
+	}
+	if fset.File(a.Pos(18)) != a || fset.File(b.Pos(0)) != b {
+		t.Error("Expected the ends of the files to stay apart")
+	}
+	if fset.Lookup("b.plank") != b || fset.Lookup("c.plank") != nil {
+		t.Error("Expected b.plank only, got", fset.Lookup("b.plank"), fset.Lookup("c.plank"))
+	}
+	if p := fset.Pos(Position{File: "b.plank", Offset: 4}); p != b.Pos(4) {
+		t.Error("Expected the compact position of b.plank:1:5, got", p)
+	}
+	for _, pos := range []Position{{File: "b.plank", Offset: -1}, {File: "b.plank", Offset: b.Size() + 1}, {File: "c.plank"}} {
+		if p := fset.Pos(pos); p != NoPos {
+			t.Error("Expected", NoPos, "for", pos, "got", p)
+		}
+	}
+
+	again := fset.AddFile("a.plank", "plot [1 2]\naxis x\n")
+	if fset.Lookup("a.plank") != again || fset.File(a.Pos(16)) != a {
+		t.Error("Expected the name to find the last file and the Pos its own file")
+	}
+}
+
+func TestPositionString(t *testing.T) {
+	if s := (Position{File: "a.plank", Line: 1, Column: 4}).String(); s != "a.plank:2:5" {
+		t.Error("Expected a.plank:2:5, got", s)
+	}
+	if s := (Position{Line: 1, Column: 4}).String(); s != "2:5" {
+		t.Error("Expected 2:5, got", s)
+	}
+}
+
+func TestFileSetConcurrent(t *testing.T) {
+	fset := NewFileSet()
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f := fset.AddFile("a.plank", "plot [1 2]")
+			if fset.File(f.Pos(3)) != f {
+				t.Error("Expected the file of its positions")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,78 @@
+// Package vfs provides file systems of OS files for goplank: every OS file, or the files under a root directory.
+// Any other fs.FS, like an embed.FS, may be used instead.
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrPathEscapes is matched by the errors of the paths leaving the root of a Root file system.
+var ErrPathEscapes = errors.New("path escapes from the root")
+
+// OS returns the file system of every OS file, named by its slash-separated path, absolute or relative to the working
+// directory. Unlike the file systems of the standard library, it accepts any path: use Root for untrusted sources.
+func OS() fs.FS {
+	return osFS{}
+}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.FromSlash(name))
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.FromSlash(name))
+}
+
+// RootFS is the file system of the OS files under a root directory. It rejects the paths leaving the root, with ..
+// or through a symbolic link, with an error matching ErrPathEscapes.
+type RootFS struct {
+	dir  string // the root, without symbolic links
+	root *os.Root
+}
+
+// Root returns the file system of the files under the directory dir. It is closed with Close.
+func Root(dir string) (*RootFS, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		root.Close()
+		return nil, err
+	}
+	return &RootFS{dir, root}, nil
+}
+
+func (r *RootFS) Open(name string) (fs.File, error) {
+	if r.escapes(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: ErrPathEscapes}
+	}
+	return r.root.Open(filepath.FromSlash(name)) // which still rejects the links changed since
+}
+
+// Close closes the root directory; the files opened before stay open.
+func (r *RootFS) Close() error {
+	return r.root.Close()
+}
+
+// escapes returns true if name leaves the root, lexically or through a symbolic link.
+func (r *RootFS) escapes(name string) bool {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return true
+	}
+	for ; name != "."; name = filepath.Dir(name) {
+		real, err := filepath.EvalSymlinks(filepath.Join(r.dir, name))
+		if err != nil { // a missing file, reported by Open, in a directory which may be a link
+			continue
+		}
+		rel, err := filepath.Rel(r.dir, real)
+		return err != nil || !filepath.IsLocal(rel)
+	}
+	return false
+}
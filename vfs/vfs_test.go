@@ -0,0 +1,58 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"secret.plank": "let s 1", "root/sub/a.plank": "plot [1 2]"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.plank"), filepath.Join(root, "link.plank")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(root, "up")); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := Root(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+	if b, err := fs.ReadFile(fsys, "sub/a.plank"); err != nil || string(b) != "plot [1 2]" {
+		t.Error("Expected the file under the root, got", string(b), err)
+	}
+	for _, name := range []string{"../secret.plank", "sub/../../secret.plank", "link.plank", "up/missing.plank", filepath.ToSlash(filepath.Join(dir, "secret.plank"))} {
+		if _, err := fs.ReadFile(fsys, name); !errors.Is(err, ErrPathEscapes) {
+			t.Error("Expected", ErrPathEscapes, "for", name, "got", err)
+		}
+	}
+	if b, err := fs.ReadFile(fsys, "sub/../sub/a.plank"); err != nil || string(b) != "plot [1 2]" {
+		t.Error("Expected the file under the root through .., got", string(b), err)
+	}
+	if _, err := fs.ReadFile(fsys, "missing.plank"); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected", fs.ErrNotExist, "got", err)
+	}
+}
+
+func TestOS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.plank")
+	if err := os.WriteFile(path, []byte("plot [1 2]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := fs.ReadFile(OS(), filepath.ToSlash(path)); err != nil || string(b) != "plot [1 2]" {
+		t.Error("Expected the file at its absolute path, got", string(b), err)
+	}
+}